@@ -2,13 +2,40 @@
 package circuit
 
 import (
+	"context"
 	"errors"
-	"sync/atomic"
+	"sync"
 	"time"
 )
 
 var ErrBreakerOpen = errors.New("breaker open")
 
+// State is the state of a Breaker.
+type State uint8
+
+const (
+	// StateClosed lets every call through while counting failures.
+	StateClosed State = iota
+	// StateOpen rejects every call with ErrBreakerOpen until the Timeout has
+	// passed.
+	StateOpen
+	// StateHalfOpen lets a limited number of trial calls through to decide
+	// whether to close or open the breaker again.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
 // NewBreaker creates an instance of Breaker with a given threshold and
 // timeout.
 func NewBreaker(threshold int64, timeout time.Duration) *Breaker {
@@ -18,47 +45,197 @@ func NewBreaker(threshold int64, timeout time.Duration) *Breaker {
 	}
 }
 
-// Breaker maintains the state of the circuit breaker. It tracks consecutive
-// failures and the last failure timestamp.
+// NewRateBreaker creates an instance of Breaker which opens once at least
+// percent of the calls over the given window failed. It only opens once
+// minRequests calls were made within the window.
+func NewRateBreaker(percent float64, minRequests int64, window, timeout time.Duration) *Breaker {
+	return &Breaker{
+		Window:         window,
+		MinRequests:    minRequests,
+		FailurePercent: percent,
+		Timeout:        timeout,
+	}
+}
+
+// Breaker maintains the state of the circuit breaker.
+//
+// It starts closed and tracks consecutive failures, or the failure rate over
+// Window if set. Once Threshold or FailurePercent is reached it opens,
+// rejecting calls until Timeout has passed. It then becomes
+// half-open and lets up to HalfOpenMax trial calls through at once. A failed
+// trial opens it again while SuccessThreshold consecutive successful trials
+// close it.
 type Breaker struct {
 	// Threshold is the number of times consecutive failures may occur
-	// before the breaker gets flipped.
+	// before the breaker gets flipped. It is not used if Window is set.
 	// NOTE: This variable is not safe to change while concurrently calling Run(...).
 	Threshold int64
-	// Timeout if the duration to wait before retrying after a flip or after
-	// the last attempt in a half-open state.
+	// Window is the period over which the failure rate is measured. If set,
+	// the breaker is flipped based on FailurePercent instead of Threshold.
+	// NOTE: This variable is not safe to change after the first call to Run(...).
+	Window time.Duration
+	// WindowBuckets is the number of buckets Window is split into. Calls
+	// drop out of the window one bucket at a time. Zero means 10.
+	// NOTE: This variable is not safe to change after the first call to Run(...).
+	WindowBuckets int
+	// MinRequests is the number of calls which must be made within Window
+	// before the failure rate is considered.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	MinRequests int64
+	// FailurePercent is the percentage (0-100) of failed calls within Window
+	// at which the breaker gets flipped. Zero disables it, e.g. to only use
+	// SlowCallPercent.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	FailurePercent float64
+	// SlowCallDuration is the duration after which a call is considered slow,
+	// even if it succeeds. Slow calls count as failures unless Window and
+	// SlowCallPercent are set. Zero disables slow call detection.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	SlowCallDuration time.Duration
+	// SlowCallPercent is the percentage (0-100) of slow calls within Window
+	// at which the breaker gets flipped. It allows slow calls to be tracked
+	// separately from failures.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	SlowCallPercent float64
+	// Timeout is the duration the breaker stays open before it becomes
+	// half-open and lets trial calls through.
 	// NOTE: This variable is not safe to change while concurrently calling Run(...).
 	Timeout time.Duration
+	// HalfOpenMax is the number of trial calls allowed at once while
+	// half-open. Zero means 1.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	HalfOpenMax int64
+	// SuccessThreshold is the number of consecutive successful trial calls
+	// needed to close the breaker. Zero means 1.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	SuccessThreshold int64
+
+	// OnStateChange is called after every state change. Changes are passed to
+	// OnStateChange and Events one at a time in the order they happened,
+	// possibly from a goroutine other than the one causing them. The breaker's
+	// lock is not held, so it is safe to call methods of the breaker. See
+	// LogStateChange.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	OnStateChange func(from, to State, reason string)
+	// Events receives every state change if set. Sends do not block; events
+	// are dropped if the channel is not ready.
+	// NOTE: This variable is not safe to change while concurrently calling Run(...).
+	Events chan<- Event
 
-	// failures is protected with atomic
+	mu    sync.Mutex
+	state State
+	// generation is incremented on every state change so that results of
+	// calls started in a previous state are ignored.
+	generation uint64
+	// failures counts consecutive failures while closed
 	failures int64
-	// timestamp (unix nanoseconds) is protected with atomic
-	timestamp int64
+	// win counts calls while closed if Window is set
+	win *window
+	// successes counts consecutive successful trials while half-open
+	successes int64
+	// probes counts trial calls in flight while half-open
+	probes int64
+	// openedAt is when the breaker last opened
+	openedAt time.Time
+	// pending holds state changes to emit once the lock is released
+	pending []Event
+	// emitting is set while a goroutine is emitting pending state changes
+	emitting bool
+}
+
+// Event describes a state change of a Breaker.
+type Event struct {
+	From, To State
+	Reason   string
+	Time     time.Time
 }
 
 // Run a function and return the result or simply return an ErrBreakerOpen
-// if the threshold for consecutive failures has been reached.
+// if the breaker is open or, while half-open, HalfOpenMax trial calls are
+// already in flight. The result is recorded as described for Breaker. A panic
+// in f counts as a failure and is not recovered.
 func (b *Breaker) Run(f func() error) error {
-	if !b.allowed() {
-		return ErrBreakerOpen
+	return b.run(nil, f)
+}
+
+// RunContext is like Run but passes ctx to f. It returns ctx.Err() without
+// calling f if ctx is already done. Errors returned after ctx was canceled are
+// not added to the failure count as the call was abandoned by the caller
+// rather than failed by the downstream. An exceeded deadline still counts as a
+// failure.
+func (b *Breaker) RunContext(ctx context.Context, f func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+	return b.run(ctx, func() error { return f(ctx) })
+}
+
+// RunWithFallback is like RunContext but calls fallback with the error if the
+// breaker is open or the call fails. The result of fallback is returned
+// instead.
+func (b *Breaker) RunWithFallback(ctx context.Context, f func(context.Context) error, fallback func(context.Context, error) error) error {
+	if err := b.RunContext(ctx, f); err != nil {
+		return fallback(ctx, err)
+	}
+	return nil
+}
 
-	if err := f(); err != nil {
-		if e, ok := err.(ignore); ok {
-			return e
+// run calls f, ignoring its error if ctx is non-nil and was canceled.
+func (b *Breaker) run(ctx context.Context, f func() error) error {
+	gen, err := b.before()
+	if err != nil {
+		return err
+	}
+
+	// Record a failure if f panics (or calls runtime.Goexit) so that a
+	// half-open breaker does not wait for the trial call forever.
+	var returned bool
+	defer func() {
+		if !returned {
+			b.after(gen, result{err: errPanicked})
 		}
+	}()
 
-		atomic.AddInt64(&b.failures, 1)
-		b.setTimer()
+	start := time.Now()
+	err = f()
+	returned = true
+	slow := b.SlowCallDuration > 0 && time.Since(start) > b.SlowCallDuration
+
+	if e, ok := err.(ignore); ok {
+		b.after(gen, result{ignored: true})
+		// Return the original error for later checking
+		return e.error
+	}
+	if err != nil && ctx != nil && ctx.Err() == context.Canceled {
+		b.after(gen, result{ignored: true})
 		return err
 	}
 
-	atomic.StoreInt64(&b.failures, 0)
-	return nil
+	b.after(gen, result{err: err, slow: slow})
+	return err
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	s := b.current(time.Now())
+	b.unlock()
+	return s
+}
+
+// errPanicked is recorded as the result of a call which did not return.
+var errPanicked = errors.New("call panicked")
+
+// result is the outcome of a call.
+type result struct {
+	err     error
+	slow    bool
+	ignored bool
 }
 
 // Ignore wraps an error returned by a function invoked in Run. It will ensure
-// the error is not added to the failure count.
+// the error is not added to the failure count. Run returns the original
+// error.
 func Ignore(err error) error {
 	return ignore{err}
 }
@@ -67,22 +244,161 @@ type ignore struct {
 	error
 }
 
-func (b *Breaker) allowed() bool {
-	fails := atomic.LoadInt64(&b.failures)
+// before decides whether a call may go ahead and returns the generation it
+// belongs to.
+func (b *Breaker) before() (uint64, error) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	switch b.current(time.Now()) {
+	case StateOpen:
+		return 0, ErrBreakerOpen
+	case StateHalfOpen:
+		if b.probes >= atLeastOne(b.HalfOpenMax) {
+			return 0, ErrBreakerOpen
+		}
+		b.probes++
+	}
+
+	return b.generation, nil
+}
+
+// after records the result of a call which started in generation gen.
+func (b *Breaker) after(gen uint64, r result) {
+	b.mu.Lock()
+	defer b.unlock()
+
+	now := time.Now()
+	state := b.current(now)
+	if gen != b.generation {
+		return
+	}
 
-	if fails >= b.Threshold {
-		since := time.Now().Sub(time.Unix(0, b.timestamp))
-		if since > b.Timeout {
-			// Allow one through and start the timer again
-			b.setTimer()
-			return true
+	switch state {
+	case StateClosed:
+		switch {
+		case r.ignored:
+		case b.Window > 0:
+			b.record(now, r)
+		case r.err != nil || r.slow:
+			b.failures++
+			if b.failures >= b.Threshold {
+				b.setState(StateOpen, now, "failure threshold reached")
+			}
+		default:
+			b.failures = 0
 		}
-		return false
+	case StateHalfOpen:
+		b.probes--
+		switch {
+		case r.ignored:
+		case r.err != nil:
+			b.setState(StateOpen, now, "trial call failed")
+		case r.slow:
+			b.setState(StateOpen, now, "trial call was slow")
+		default:
+			b.successes++
+			if b.successes >= atLeastOne(b.SuccessThreshold) {
+				b.setState(StateClosed, now, "trial calls succeeded")
+			}
+		}
+	}
+}
+
+// record adds a call to the window and opens the breaker if the failure rate
+// or slow call rate was reached. It must be called while holding b.mu.
+func (b *Breaker) record(now time.Time, r result) {
+	if b.win == nil {
+		n := b.WindowBuckets
+		if n == 0 {
+			n = 10
+		}
+		b.win = newWindow(b.Window, n)
+	}
+	failed := r.err != nil || (r.slow && b.SlowCallPercent == 0)
+	b.win.add(now, failed, r.slow)
+
+	c := b.win.counts(now)
+	switch {
+	case c.total < b.MinRequests:
+	case b.FailurePercent > 0 && reached(c.failures, c.total, b.FailurePercent):
+		b.setState(StateOpen, now, "failure rate reached")
+	case b.SlowCallPercent > 0 && reached(c.slow, c.total, b.SlowCallPercent):
+		b.setState(StateOpen, now, "slow call rate reached")
+	}
+}
+
+// reached reports whether n out of total is at least percent.
+func reached(n, total int64, percent float64) bool {
+	return n > 0 && float64(n)*100 >= percent*float64(total)
+}
+
+// current returns the state, moving from open to half-open once the Timeout
+// has passed. It must be called while holding b.mu.
+func (b *Breaker) current(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) > b.Timeout {
+		b.setState(StateHalfOpen, now, "timeout elapsed")
+	}
+	return b.state
+}
+
+// setState must be called while holding b.mu.
+func (b *Breaker) setState(s State, now time.Time, reason string) {
+	if b.OnStateChange != nil || b.Events != nil {
+		b.pending = append(b.pending, Event{From: b.state, To: s, Reason: reason, Time: now})
 	}
 
-	return true
+	b.state = s
+	b.generation++
+	b.failures = 0
+	if b.win != nil {
+		b.win.reset()
+	}
+	b.successes = 0
+	b.probes = 0
+	if s == StateOpen {
+		b.openedAt = now
+	}
 }
 
-func (b *Breaker) setTimer() {
-	atomic.StoreInt64(&b.timestamp, time.Now().UnixNano())
+// unlock releases b.mu and then emits any pending state changes. Only one
+// goroutine emits at a time, taking over changes queued by others meanwhile,
+// so that they are emitted in order.
+func (b *Breaker) unlock() {
+	if len(b.pending) == 0 || b.emitting {
+		b.mu.Unlock()
+		return
+	}
+
+	b.emitting = true
+	for len(b.pending) > 0 {
+		events := b.pending
+		b.pending = nil
+		b.mu.Unlock()
+		b.emit(events)
+		b.mu.Lock()
+	}
+	b.emitting = false
+	b.mu.Unlock()
+}
+
+func (b *Breaker) emit(events []Event) {
+	for _, e := range events {
+		if b.OnStateChange != nil {
+			b.OnStateChange(e.From, e.To, e.Reason)
+		}
+		if b.Events != nil {
+			select {
+			case b.Events <- e:
+			default:
+			}
+		}
+	}
+}
+
+func atLeastOne(n int64) int64 {
+	if n < 1 {
+		return 1
+	}
+	return n
 }
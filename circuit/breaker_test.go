@@ -1,7 +1,9 @@
 package circuit_test
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -73,3 +75,424 @@ func TestRunErrsTimeout(t *testing.T) {
 		t.Fatalf("expected %v runs, got %v", exp, n)
 	}
 }
+
+func TestState(t *testing.T) {
+	b := circuit.NewBreaker(2, 10*time.Millisecond)
+	b.SuccessThreshold = 2
+
+	myErr := errors.New("whoops")
+	fail := func() error { return myErr }
+	succeed := func() error { return nil }
+
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s, got: %s", circuit.StateClosed, s)
+	}
+
+	b.Run(fail)
+	b.Run(fail)
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s, got: %s", circuit.StateOpen, s)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if s := b.State(); s != circuit.StateHalfOpen {
+		t.Fatalf("expected %s, got: %s", circuit.StateHalfOpen, s)
+	}
+
+	// A failed trial opens the breaker again
+	b.Run(fail)
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s, got: %s", circuit.StateOpen, s)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	b.Run(succeed)
+	if s := b.State(); s != circuit.StateHalfOpen {
+		t.Fatalf("expected %s after 1 success, got: %s", circuit.StateHalfOpen, s)
+	}
+	b.Run(succeed)
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s after 2 successes, got: %s", circuit.StateClosed, s)
+	}
+}
+
+func TestHalfOpenMax(t *testing.T) {
+	b := circuit.NewBreaker(1, time.Millisecond)
+	b.HalfOpenMax = 3
+
+	b.Run(func() error { return errors.New("whoops") })
+	time.Sleep(5 * time.Millisecond)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+		rejected int
+		wg       sync.WaitGroup
+		release  = make(chan struct{})
+	)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.Run(func() error {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxSeen {
+					maxSeen = inFlight
+				}
+				mu.Unlock()
+
+				<-release
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return nil
+			})
+			if err == circuit.ErrBreakerOpen {
+				mu.Lock()
+				rejected++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Wait for every call to either start or be rejected
+	for {
+		mu.Lock()
+		done := inFlight+rejected == 50
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if maxSeen != 3 || rejected != 47 {
+		t.Fatalf("expected 3 trial calls and 47 rejections, got: %v and %v", maxSeen, rejected)
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s, got: %s", circuit.StateClosed, s)
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	b := circuit.NewBreaker(1, 10*time.Millisecond)
+
+	events := make(chan circuit.Event, 10)
+	b.Events = events
+
+	var got []string
+	b.OnStateChange = func(from, to circuit.State, reason string) {
+		// Calling back into the breaker must not deadlock
+		if s := b.State(); s != to {
+			t.Fatalf("expected %s from within callback, got: %s", to, s)
+		}
+		got = append(got, from.String()+"->"+to.String()+": "+reason)
+	}
+
+	b.Run(func() error { return errors.New("whoops") })
+	time.Sleep(20 * time.Millisecond)
+	b.Run(func() error { return nil })
+
+	expected := []string{
+		"closed->open: failure threshold reached",
+		"open->half-open: timeout elapsed",
+		"half-open->closed: trial calls succeeded",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got: %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %q, got: %q", expected[i], got[i])
+		}
+	}
+
+	if n := len(events); n != len(expected) {
+		t.Fatalf("expected %v events, got: %v", len(expected), n)
+	}
+	if e := <-events; e.From != circuit.StateClosed || e.To != circuit.StateOpen || e.Time.IsZero() {
+		t.Fatalf("expected closed->open event, got: %+v", e)
+	}
+}
+
+func TestEventsDoNotBlock(t *testing.T) {
+	b := circuit.NewBreaker(1, time.Second)
+	b.Events = make(chan circuit.Event)
+
+	done := make(chan struct{})
+	go func() {
+		b.Run(func() error { return errors.New("whoops") })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run not to block on a full events channel")
+	}
+}
+
+func TestRateBreaker(t *testing.T) {
+	b := circuit.NewRateBreaker(50, 4, time.Minute, time.Minute)
+
+	myErr := errors.New("whoops")
+	fail := func() error { return myErr }
+	succeed := func() error { return nil }
+
+	// Alternating failures never trip a consecutive-failure breaker but
+	// reach a 50% failure rate.
+	b.Run(fail)
+	b.Run(succeed)
+	b.Run(fail)
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s below MinRequests, got: %s", circuit.StateClosed, s)
+	}
+
+	b.Run(succeed)
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s at 50%% failures, got: %s", circuit.StateOpen, s)
+	}
+	if err := b.Run(succeed); err != circuit.ErrBreakerOpen {
+		t.Fatalf("expected %v, got: %v", circuit.ErrBreakerOpen, err)
+	}
+}
+
+func TestRateBreakerBelowRate(t *testing.T) {
+	b := circuit.NewRateBreaker(50, 4, time.Minute, time.Minute)
+
+	for i := 0; i < 10; i++ {
+		b.Run(func() error { return nil })
+		b.Run(func() error { return nil })
+		b.Run(func() error { return errors.New("whoops") })
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s at 33%% failures, got: %s", circuit.StateClosed, s)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	b := circuit.NewBreaker(1, time.Minute)
+
+	myErr := errors.New("whoops")
+	for i := 0; i < 3; i++ {
+		if err := b.Run(func() error { return circuit.Ignore(myErr) }); err != myErr {
+			t.Fatalf("expected %v, got: %v", myErr, err)
+		}
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s, got: %s", circuit.StateClosed, s)
+	}
+}
+
+func TestSlowCall(t *testing.T) {
+	b := circuit.NewBreaker(2, time.Minute)
+	b.SlowCallDuration = 5 * time.Millisecond
+
+	slow := func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	if err := b.Run(slow); err != nil {
+		t.Fatalf("expected nil error for a slow call, got: %v", err)
+	}
+	b.Run(func() error { return nil })
+	b.Run(slow)
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s, got: %s", circuit.StateClosed, s)
+	}
+
+	b.Run(slow)
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s after consecutive slow calls, got: %s", circuit.StateOpen, s)
+	}
+}
+
+func TestSlowCallRate(t *testing.T) {
+	b := circuit.NewRateBreaker(50, 4, time.Minute, time.Minute)
+	b.SlowCallDuration = 5 * time.Millisecond
+	b.SlowCallPercent = 75
+
+	slow := func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	// Slow calls are tracked separately so 50% slow calls is not enough
+	b.Run(slow)
+	b.Run(slow)
+	b.Run(func() error { return nil })
+	b.Run(func() error { return nil })
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s at 50%% slow calls, got: %s", circuit.StateClosed, s)
+	}
+
+	b.Run(slow)
+	b.Run(slow)
+	b.Run(slow)
+	b.Run(slow)
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s at 75%% slow calls, got: %s", circuit.StateOpen, s)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	b := circuit.NewBreaker(1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var called bool
+	err := b.RunContext(ctx, func(context.Context) error {
+		called = true
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected %v, got: %v", context.Canceled, err)
+	}
+	if called {
+		t.Fatal("expected func not to be called with a done context")
+	}
+
+	// Cancellation during the call is not a failure
+	ctx, cancel = context.WithCancel(context.Background())
+	err = b.RunContext(ctx, func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected %v, got: %v", context.Canceled, err)
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s after cancellation, got: %s", circuit.StateClosed, s)
+	}
+
+	myErr := errors.New("whoops")
+	if err := b.RunContext(context.Background(), func(context.Context) error { return myErr }); err != myErr {
+		t.Fatalf("expected %v, got: %v", myErr, err)
+	}
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s, got: %s", circuit.StateOpen, s)
+	}
+}
+
+func TestRunWithFallback(t *testing.T) {
+	b := circuit.NewBreaker(1, time.Minute)
+
+	myErr := errors.New("whoops")
+	var fallbackErrs []error
+	fallback := func(_ context.Context, err error) error {
+		fallbackErrs = append(fallbackErrs, err)
+		return nil
+	}
+	run := func(err error) error {
+		return b.RunWithFallback(context.Background(), func(context.Context) error { return err }, fallback)
+	}
+
+	if err := run(nil); err != nil || len(fallbackErrs) != 0 {
+		t.Fatalf("expected no fallback on success, got: %v, %v", err, fallbackErrs)
+	}
+	if err := run(myErr); err != nil {
+		t.Fatalf("expected fallback result, got: %v", err)
+	}
+	if err := run(nil); err != nil {
+		t.Fatalf("expected fallback result, got: %v", err)
+	}
+
+	if len(fallbackErrs) != 2 || fallbackErrs[0] != myErr || fallbackErrs[1] != circuit.ErrBreakerOpen {
+		t.Fatalf("expected fallback with %v then %v, got: %v", myErr, circuit.ErrBreakerOpen, fallbackErrs)
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	b := circuit.NewBreaker(1, 10*time.Millisecond)
+	b.Run(func() error { return errors.New("whoops") })
+	time.Sleep(20 * time.Millisecond)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to be passed on")
+			}
+		}()
+		b.Run(func() error { panic("ut oh") })
+	}()
+
+	// The panicking trial call counts as a failure
+	if s := b.State(); s != circuit.StateOpen {
+		t.Fatalf("expected %s, got: %s", circuit.StateOpen, s)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := b.Run(func() error { return nil }); err != nil {
+		t.Fatalf("expected the breaker to recover, got: %v", err)
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s, got: %s", circuit.StateClosed, s)
+	}
+}
+
+func TestOnStateChangeOrder(t *testing.T) {
+	b := circuit.NewBreaker(1, 0)
+	b.HalfOpenMax = 4
+
+	var (
+		mu     sync.Mutex
+		events []circuit.Event
+	)
+	b.OnStateChange = func(from, to circuit.State, reason string) {
+		// Give other goroutines a chance to change the state meanwhile
+		time.Sleep(time.Microsecond)
+		mu.Lock()
+		events = append(events, circuit.Event{From: from, To: to, Reason: reason})
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				b.Run(func() error {
+					if (i+j)%2 == 0 {
+						return errors.New("whoops")
+					}
+					return nil
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	b.State()
+
+	if len(events) == 0 {
+		t.Fatal("expected state changes")
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].From != events[i-1].To {
+			t.Fatalf("expected change %v to start from %s, got: %+v", i, events[i-1].To, events[i])
+		}
+	}
+}
+
+func TestRateBreakerSlowCallsOnly(t *testing.T) {
+	b := circuit.NewRateBreaker(0, 4, time.Minute, time.Minute)
+	b.SlowCallDuration = time.Minute
+	b.SlowCallPercent = 50
+
+	b.Run(func() error { return errors.New("whoops") })
+	for i := 0; i < 19; i++ {
+		b.Run(func() error { return nil })
+	}
+	if s := b.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s without a FailurePercent, got: %s", circuit.StateClosed, s)
+	}
+}
@@ -0,0 +1,21 @@
+package circuit
+
+import "github.com/upgear/go-kit/log"
+
+// logger is used by LogStateChange. Its level can be set separately with
+// e.g. `LOG_LEVEL=info,circuit=warn`, see log.Named.
+var logger = log.Named("circuit")
+
+// LogStateChange returns a function for Breaker.OnStateChange which logs
+// transitions of the named breaker. Opening is logged as a warning, other
+// transitions as info.
+func LogStateChange(name string) func(from, to State, reason string) {
+	return func(from, to State, reason string) {
+		kvs := log.KV("breaker", name, "from", from, "to", to, "reason", reason)
+		if to == StateOpen {
+			logger.Warn("circuit breaker opened", kvs)
+			return
+		}
+		logger.Info("circuit breaker state changed", kvs)
+	}
+}
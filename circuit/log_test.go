@@ -0,0 +1,26 @@
+package circuit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/upgear/go-kit/circuit"
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logtest"
+)
+
+func TestLogStateChange(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	b := circuit.NewBreaker(1, time.Second)
+	b.OnStateChange = circuit.LogStateChange("db")
+	b.Run(func() error { return errors.New("whoops") })
+
+	rec.AssertLogged(log.LevelWarn, "circuit breaker opened", log.M{
+		"breaker": "db",
+		"from":    "closed",
+		"to":      "open",
+		"reason":  "failure threshold reached",
+	})
+}
@@ -0,0 +1,74 @@
+package circuit
+
+import "time"
+
+// window counts calls over a sliding period of time split into buckets.
+// Buckets older than the period are discarded lazily as time moves on.
+type window struct {
+	buckets []bucket
+	// width is the duration covered by each bucket
+	width time.Duration
+}
+
+type bucket struct {
+	// epoch identifies the period of time the bucket currently counts
+	epoch int64
+	counts
+}
+
+type counts struct {
+	total    int64
+	failures int64
+	slow     int64
+}
+
+func newWindow(d time.Duration, n int) *window {
+	if n < 1 {
+		n = 1
+	}
+	width := d / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+	return &window{buckets: make([]bucket, n), width: width}
+}
+
+// add records a call made at the given time.
+func (w *window) add(now time.Time, failed, slow bool) {
+	epoch := int64(now.UnixNano()) / int64(w.width)
+	b := &w.buckets[epoch%int64(len(w.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+
+	b.total++
+	if failed {
+		b.failures++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// counts returns the number of calls within the window ending at the given
+// time.
+func (w *window) counts(now time.Time) counts {
+	epoch := int64(now.UnixNano()) / int64(w.width)
+	oldest := epoch - int64(len(w.buckets))
+
+	var c counts
+	for _, b := range w.buckets {
+		if b.epoch > oldest && b.epoch <= epoch {
+			c.total += b.total
+			c.failures += b.failures
+			c.slow += b.slow
+		}
+	}
+	return c
+}
+
+func (w *window) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
@@ -0,0 +1,35 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	w := newWindow(time.Second, 10)
+	start := time.Unix(1000, 0)
+
+	w.add(start, true, false)
+	w.add(start.Add(100*time.Millisecond), false, true)
+	w.add(start.Add(900*time.Millisecond), false, false)
+
+	if c := w.counts(start.Add(900 * time.Millisecond)); c.total != 3 || c.failures != 1 || c.slow != 1 {
+		t.Fatalf("expected 3 calls, 1 failure and 1 slow call, got: %+v", c)
+	}
+
+	// The first bucket has slid out of the window
+	if c := w.counts(start.Add(time.Second)); c.total != 2 || c.failures != 0 {
+		t.Fatalf("expected 2 calls and 0 failures, got: %+v", c)
+	}
+
+	// Reusing a bucket discards its old counts
+	w.add(start.Add(2*time.Second), true, false)
+	if c := w.counts(start.Add(2 * time.Second)); c.total != 1 || c.failures != 1 {
+		t.Fatalf("expected 1 call and 1 failure, got: %+v", c)
+	}
+
+	w.reset()
+	if c := w.counts(start.Add(2 * time.Second)); c.total != 0 {
+		t.Fatalf("expected 0 calls after reset, got: %+v", c)
+	}
+}
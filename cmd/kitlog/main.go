@@ -0,0 +1,169 @@
+// Command kitlog reads logs written by the log package from stdin or files
+// and prints them in a friendlier format.
+//
+// Usage:
+//
+//	kitlog [flags] [file ...]
+//
+// Examples:
+//
+//	# Pretty print warnings and above
+//	kitlog -level warn app.log
+//
+//	# Follow a file, only printing failed API requests
+//	kitlog -f -filter 'status>=500 AND path~"^/api"' app.log
+//
+//	# Convert to JSON lines containing only a couple of fields
+//	my-service 2>&1 | kitlog -json -fields status,path
+//
+// Lines which were not written by the log package are skipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+func main() {
+	var (
+		level  = flag.String("level", "", "minimum level to print: debug, info, warn, error, fatal or panic")
+		filter = flag.String("filter", "", `filter expression, e.g. 'status>=500 AND path~"^/api"'`)
+		fields = flag.String("fields", "", "comma-separated list of fields to print (default all)")
+		asJSON = flag.Bool("json", false, "print JSON lines rather than pretty output")
+		follow = flag.Bool("f", false, "keep reading files as they grow")
+		color  = flag.String("color", "auto", "colorize pretty output: auto, always or never")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: kitlog [flags] [file ...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	expr := *filter
+	if *level != "" {
+		expr = joinExprs("lvl>="+*level, expr)
+	}
+	match, err := logparse.Compile(expr)
+	if err != nil {
+		fatalf("invalid filter: %s", err)
+	}
+
+	p := &printer{
+		w:      os.Stdout,
+		json:   *asJSON,
+		fields: splitFields(*fields),
+	}
+	switch *color {
+	case "always":
+		p.color = true
+	case "never":
+	case "auto":
+		p.color = isTerminal(os.Stdout)
+	default:
+		fatalf("invalid -color value %q", *color)
+	}
+
+	if flag.NArg() == 0 {
+		if err := scan(os.Stdin, match, p); err != nil {
+			fatalf("stdin: %s", err)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fatalf("%s", err)
+		}
+
+		if !*follow {
+			err := scan(f, match, p)
+			f.Close()
+			if err != nil {
+				fatalf("%s: %s", name, err)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, f *os.File) {
+			defer wg.Done()
+			if err := scan(&followReader{f: f, poll: 250 * time.Millisecond}, match, p); err != nil {
+				fatalf("%s: %s", name, err)
+			}
+		}(name, f)
+	}
+	wg.Wait()
+}
+
+func scan(r io.Reader, match logparse.Filter, p *printer) error {
+	s := logparse.NewScanner(r)
+	for s.Scan() {
+		if l := s.Line(); match(l) {
+			if err := p.print(l); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Err()
+}
+
+// followReader blocks at the end of a file until more data is written to it,
+// similar to `tail -f`. If the file is truncated it starts again from the
+// beginning.
+type followReader struct {
+	f    *os.File
+	poll time.Duration
+}
+
+func (r *followReader) Read(b []byte) (int, error) {
+	for {
+		n, err := r.f.Read(b)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		if fi, err := r.f.Stat(); err == nil {
+			if off, err := r.f.Seek(0, io.SeekCurrent); err == nil && fi.Size() < off {
+				r.f.Seek(0, io.SeekStart)
+				continue
+			}
+		}
+		time.Sleep(r.poll)
+	}
+}
+
+func joinExprs(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return fmt.Sprintf("(%s) AND (%s)", a, b)
+}
+
+func splitFields(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "kitlog: "+format+"\n", args...)
+	os.Exit(1)
+}
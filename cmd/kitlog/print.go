@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+// printer writes Lines either as pretty text or JSON lines. It is safe for
+// concurrent use.
+type printer struct {
+	w      io.Writer
+	json   bool
+	color  bool
+	fields []string
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (p *printer) print(l logparse.Line) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf.Reset()
+	if p.json {
+		p.formatJSON(l)
+	} else {
+		p.formatPretty(l)
+	}
+
+	_, err := p.w.Write(p.buf.Bytes())
+	return err
+}
+
+// keys returns the keys of the values which should be printed.
+func (p *printer) keys(l logparse.Line) []string {
+	if p.fields == nil {
+		return l.Keys
+	}
+	var keys []string
+	for _, k := range p.fields {
+		if _, ok := l.Values[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (p *printer) formatPretty(l logparse.Line) {
+	e := &log.Entry{Time: l.Time.Local(), Level: l.Level, Message: l.Message}
+	for _, k := range p.keys(l) {
+		e.Fields = append(e.Fields, log.Field{Key: k, Value: l.Values[k]})
+	}
+
+	enc := log.ConsoleEncoder{NoColor: !p.color, TimeFormat: "2006-01-02 15:04:05"}
+	p.buf.Write(enc.Encode(e))
+}
+
+func (p *printer) formatJSON(l logparse.Line) {
+	b := &p.buf
+
+	b.WriteString(`{"ts":`)
+	writeJSONString(b, l.Time.Format(time.RFC3339))
+	b.WriteString(`,"lvl":`)
+	writeJSONString(b, l.Level.String())
+	b.WriteString(`,"msg":`)
+	writeJSONString(b, l.Message)
+
+	for _, k := range p.keys(l) {
+		b.WriteByte(',')
+		writeJSONString(b, k)
+		b.WriteByte(':')
+		writeJSONString(b, l.Values[k])
+	}
+	b.WriteString("}\n")
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	// Marshalling a string never fails
+	btys, _ := json.Marshal(s)
+	b.Write(btys)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+const testLine = `ts="2017-06-01T10:20:30Z" lvl=warning msg="slow request" path=/api dur=2.5 note="a b"`
+
+func TestPrintPretty(t *testing.T) {
+	var buf bytes.Buffer
+	p := &printer{w: &buf}
+
+	l := logparse.ParseLine(testLine)
+	if err := p.print(l); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := l.Time.Local().Format("2006-01-02 15:04:05") + ` WARN  slow request path=/api dur=2.5 note="a b"` + "\n"
+	if buf.String() != exp {
+		t.Fatalf("expected %q, got: %q", exp, buf.String())
+	}
+}
+
+func TestPrintJSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	p := &printer{w: &buf, json: true, fields: []string{"note", "missing", "path"}}
+
+	if err := p.print(logparse.ParseLine(testLine)); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"ts":"2017-06-01T10:20:30Z","lvl":"warning","msg":"slow request","note":"a b","path":"/api"}` + "\n"
+	if buf.String() != exp {
+		t.Fatalf("expected %q, got: %q", exp, buf.String())
+	}
+}
+
+func TestScanFilter(t *testing.T) {
+	var buf bytes.Buffer
+	p := &printer{w: &buf, json: true, fields: []string{}}
+
+	input := testLine + "\n" +
+		`ts="2017-06-01T10:20:31Z" lvl=info msg=ok path=/api` + "\n" +
+		"garbage\n"
+
+	if err := scan(bytes.NewBufferString(input), logparse.MustCompile(joinExprs("lvl>=warn", "")), p); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"ts":"2017-06-01T10:20:30Z","lvl":"warning","msg":"slow request"}` + "\n"
+	if buf.String() != exp {
+		t.Fatalf("expected %q, got: %q", exp, buf.String())
+	}
+}
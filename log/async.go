@@ -0,0 +1,156 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned when writing to a closed AsyncWriter.
+var ErrClosed = errors.New("log: writer closed")
+
+// FullPolicy specifies what an AsyncWriter does when its buffer is full.
+type FullPolicy uint8
+
+const (
+	// FullBlock waits for space in the buffer. No lines are lost but logging
+	// calls may be slowed down by the underlying writer.
+	FullBlock FullPolicy = iota
+	// FullDrop discards the line being written. See AsyncWriter.Dropped.
+	FullDrop
+)
+
+// NewAsyncWriter creates an AsyncWriter which buffers up to size lines before
+// applying the given policy.
+func NewAsyncWriter(w io.Writer, size int, policy FullPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	a := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		ring:   make([][]byte, size),
+		done:   make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// AsyncWriter writes to an underlying io.Writer from a background goroutine
+// so that logging calls do not wait on slow disks or pipes. Each call to
+// Write is buffered as a single line.
+//
+// A Logger flushes its writer (see Logger.Flush) before calling os.Exit or
+// panic for the fatal and panic levels. Call Close before a program exits
+// normally so that buffered lines are not lost.
+type AsyncWriter struct {
+	w      io.Writer
+	policy FullPolicy
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    [][]byte
+	head    int // index of the oldest line
+	n       int // number of lines in ring
+	writing bool
+	closed  bool
+	dropped uint64
+	err     error
+	done    chan struct{}
+}
+
+// Write buffers a copy of p. It never returns an error from the underlying
+// writer, see Flush.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for !a.closed && a.n == len(a.ring) {
+		if a.policy == FullDrop {
+			a.dropped++
+			return len(p), nil
+		}
+		a.cond.Wait()
+	}
+	if a.closed {
+		return 0, ErrClosed
+	}
+
+	a.ring[(a.head+a.n)%len(a.ring)] = append([]byte(nil), p...)
+	a.n++
+	a.cond.Broadcast()
+
+	return len(p), nil
+}
+
+// Flush waits until every buffered line has been written to the underlying
+// writer. It returns the first error returned by the underlying writer, if
+// any.
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for a.n > 0 || a.writing {
+		a.cond.Wait()
+	}
+	return a.err
+}
+
+// Close flushes buffered lines and stops the background goroutine. The
+// underlying writer is not closed.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrClosed
+	}
+	a.closed = true
+	a.cond.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Dropped returns the number of lines discarded because the buffer was full.
+func (a *AsyncWriter) Dropped() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for {
+		for a.n == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.n == 0 && a.closed {
+			return
+		}
+
+		p := a.ring[a.head]
+		a.ring[a.head] = nil
+		a.head = (a.head + 1) % len(a.ring)
+		a.n--
+		a.writing = true
+
+		a.mu.Unlock()
+		_, err := a.w.Write(p)
+		a.mu.Lock()
+
+		a.writing = false
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		a.cond.Broadcast()
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowWriter waits on a channel before each write.
+type slowWriter struct {
+	syncBuffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.syncBuffer.Write(p)
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	var buf syncBuffer
+	a := NewAsyncWriter(&buf, 2, FullBlock)
+	l := New(a, LevelDebug)
+
+	for i := 0; i < 100; i++ {
+		l.Info("line", M{"i": i})
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 100 || !strings.HasSuffix(lines[99], "i=99") {
+		t.Fatalf("expected 100 ordered lines, got %v ending with: %q", len(lines), lines[len(lines)-1])
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Write([]byte("x")); err != ErrClosed {
+		t.Fatalf("expected %s, got: %v", ErrClosed, err)
+	}
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	w := &slowWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, 2, FullDrop)
+
+	a.Write([]byte("1\n"))
+	// Wait for the first line to be picked up by the background goroutine
+	for {
+		a.mu.Lock()
+		writing := a.writing
+		a.mu.Unlock()
+		if writing {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 2; i <= 5; i++ {
+		a.Write([]byte("x\n"))
+	}
+
+	if exp := uint64(2); a.Dropped() != exp {
+		t.Fatalf("expected %v dropped lines, got: %v", exp, a.Dropped())
+	}
+
+	close(w.release)
+	a.Close()
+	if exp := "1\nx\nx\n"; w.String() != exp {
+		t.Fatalf("expected %q, got: %q", exp, w.String())
+	}
+}
+
+func TestPanicFlushes(t *testing.T) {
+	w := &slowWriter{release: make(chan struct{})}
+	close(w.release)
+	l := New(NewAsyncWriter(w, 10, FullBlock), LevelDebug)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+		if !strings.Contains(w.String(), "msg=boom") {
+			t.Fatalf("expected the line to be flushed before panicking, got: %q", w.String())
+		}
+	}()
+	l.Panic("boom")
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// caller returns the `dir/file.go:line` of the function skip frames above
+// the caller of caller.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "???"
+	}
+	return fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(file)), filepath.Base(file), line)
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+type causer interface {
+	Cause() error
+}
+
+// errorStack formats the stack trace recorded by github.com/pkg/errors for
+// the deepest error in err's cause chain which has one. An empty string is
+// returned if there is none.
+func errorStack(err error) string {
+	var st errors.StackTrace
+	for err != nil {
+		if s, ok := err.(stackTracer); ok {
+			st = s.StackTrace()
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+
+	frames := make([]string, len(st))
+	for i, f := range st {
+		frames[i] = fmt.Sprintf("%s:%d", f, f)
+	}
+	return strings.Join(frames, " ")
+}
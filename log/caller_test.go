@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug).With(M{"a": 1})
+	l.SetCaller(true)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("hi")
+
+	exp := fmt.Sprintf(` msg=hi caller="log/caller_test.go:%v" a=1`, line+1)
+	if !strings.HasSuffix(buf.String(), exp+"\n") {
+		t.Fatalf("expected suffix %q, got: %q", exp, buf.String())
+	}
+
+	buf.Reset()
+	l.SetCaller(false)
+	l.Info("hi")
+	if strings.Contains(buf.String(), "caller=") {
+		t.Fatalf("expected no caller, got: %q", buf.String())
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug)
+
+	err := errors.Wrap(errors.New("ut oh"), "wrapped")
+
+	l.Warn(err)
+	if strings.Contains(buf.String(), "stack=") {
+		t.Fatalf("expected no stack for warnings, got: %q", buf.String())
+	}
+
+	buf.Reset()
+	l.Error(err, M{"status": 500})
+	if !strings.Contains(buf.String(), ` status=500 `) || !strings.Contains(buf.String(), ` stack="caller_test.go:`) {
+		t.Fatalf("expected a stack, got: %q", buf.String())
+	}
+
+	if st := errorStack(fmt.Errorf("no stack")); st != "" {
+		t.Fatalf("expected no stack, got: %q", st)
+	}
+}
@@ -0,0 +1,44 @@
+package log
+
+import "context"
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx which carries l. Use FromContext or the
+// *Ctx functions (e.g. InfoCtx) to log with it further down the call chain.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext or the default
+// Logger if there is none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		return l
+	}
+	return std
+}
+
+func DebugCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelDebug, msg, kvs...)
+}
+
+func InfoCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelInfo, msg, kvs...)
+}
+
+func WarnCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelWarn, msg, kvs...)
+}
+
+func ErrorCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelError, msg, kvs...)
+}
+
+func FatalCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelFatal, msg, kvs...)
+}
+
+func PanicCtx(ctx context.Context, msg interface{}, kvs ...KeyValues) {
+	FromContext(ctx).prnt(LevelPanic, msg, kvs...)
+}
@@ -0,0 +1,175 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single line before it is encoded.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message interface{}
+	// Fields are the bound key/values followed by the key/values passed to
+	// the logging call.
+	Fields []Field
+}
+
+// Encoder formats an Entry as a line, including the trailing newline.
+type Encoder interface {
+	Encode(e *Entry) []byte
+}
+
+// ParseEncoder returns the Encoder for a format name: "logfmt" (or an empty
+// string), "json" or "console".
+func ParseEncoder(s string) (Encoder, error) {
+	switch strings.ToLower(s) {
+	case "", "logfmt":
+		return LogfmtEncoder{}, nil
+	case "json":
+		return JSONEncoder{}, nil
+	case "console":
+		return ConsoleEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", s)
+}
+
+// LogfmtEncoder writes whitespace-separated key=value pairs starting with the
+// `ts`, `lvl` and `msg` keys. Values are quoted when needed. This is the
+// default format and can be read back with the logparse package.
+type LogfmtEncoder struct{}
+
+func (LogfmtEncoder) Encode(e *Entry) []byte {
+	return []byte(fmt.Sprintf("%s %s %s%s\n",
+		kvToString("ts", e.Time.Format(time.RFC3339)),
+		kvToString("lvl", e.Level),
+		kvToString("msg", e.Message),
+		fieldsToString(e.Fields),
+	))
+}
+
+// JSONEncoder writes a JSON object per line with the `ts`, `lvl` and `msg`
+// keys followed by the fields in order. Values which can not be marshalled
+// (and errors) are written as strings.
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(e *Entry) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(`{"ts":`)
+	writeJSON(&b, e.Time.Format(time.RFC3339))
+	b.WriteString(`,"lvl":`)
+	writeJSON(&b, e.Level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, fmt.Sprint(e.Message))
+
+	for _, f := range e.Fields {
+		if len(f.Key) == 0 {
+			continue
+		}
+		b.WriteByte(',')
+		writeJSON(&b, f.Key)
+		b.WriteByte(':')
+		writeJSON(&b, f.Value)
+	}
+	b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+func writeJSON(b *bytes.Buffer, v interface{}) {
+	switch x := v.(type) {
+	case error:
+		v = x.Error()
+	case fmt.Stringer:
+		v = x.String()
+	}
+
+	btys, err := json.Marshal(v)
+	if err != nil {
+		btys, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(btys)
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiDim   = "\x1b[2m"
+	ansiBold  = "\x1b[1m"
+)
+
+var levelColors = map[Level]string{
+	LevelDebug: "\x1b[36m", // Cyan
+	LevelInfo:  "\x1b[32m", // Green
+	LevelWarn:  "\x1b[33m", // Yellow
+	LevelError: "\x1b[31m", // Red
+	LevelFatal: "\x1b[35m", // Magenta
+	LevelPanic: "\x1b[35m", // Magenta
+}
+
+// ConsoleEncoder writes colorized lines intended for people reading a
+// terminal during local development, for example:
+//
+//	15:04:05 INFO  new request method=GET path="/"
+type ConsoleEncoder struct {
+	// NoColor disables ANSI color codes.
+	NoColor bool
+	// TimeFormat is the layout of the timestamp. It defaults to "15:04:05".
+	TimeFormat string
+}
+
+func (c ConsoleEncoder) Encode(e *Entry) []byte {
+	var b bytes.Buffer
+
+	lvl := e.Level.String()
+	if e.Level == LevelWarn {
+		lvl = "warn"
+	}
+
+	layout := c.TimeFormat
+	if layout == "" {
+		layout = "15:04:05"
+	}
+
+	c.style(&b, ansiDim, e.Time.Format(layout))
+	b.WriteByte(' ')
+	c.style(&b, levelColors[e.Level], fmt.Sprintf("%-5s", strings.ToUpper(lvl)))
+	b.WriteByte(' ')
+	c.style(&b, ansiBold, fmt.Sprint(e.Message))
+
+	for _, f := range e.Fields {
+		if len(f.Key) == 0 {
+			continue
+		}
+		b.WriteByte(' ')
+		c.style(&b, ansiDim, f.Key+"=")
+		b.WriteString(consoleValue(f.Value))
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes()
+}
+
+// consoleValue formats v for people rather than parsers, so it is only quoted
+// when it would otherwise be ambiguous.
+func consoleValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func (c ConsoleEncoder) style(b *bytes.Buffer, ansi, s string) {
+	if c.NoColor || ansi == "" {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(ansi)
+	b.WriteString(s)
+	b.WriteString(ansiReset)
+}
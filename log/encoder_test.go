@@ -0,0 +1,58 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testEntry = Entry{
+	Time:    time.Date(2017, 6, 1, 10, 20, 30, 0, time.UTC),
+	Level:   LevelWarn,
+	Message: errors.New("ut oh"),
+	Fields:  KV("status", 503, "path", "/a b", "err", errors.New("bad")),
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	exp := `ts="2017-06-01T10:20:30Z" lvl=warning msg="ut oh" status=503 path="/a b" err=bad` + "\n"
+	if s := string(LogfmtEncoder{}.Encode(&testEntry)); s != exp {
+		t.Fatalf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	exp := `{"ts":"2017-06-01T10:20:30Z","lvl":"warning","msg":"ut oh","status":503,"path":"/a b","err":"bad"}` + "\n"
+	if s := string(JSONEncoder{}.Encode(&testEntry)); s != exp {
+		t.Fatalf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	exp := `10:20:30 WARN  ut oh status=503 path="/a b" err=bad` + "\n"
+	if s := string(ConsoleEncoder{NoColor: true}.Encode(&testEntry)); s != exp {
+		t.Fatalf("expected %q, got: %q", exp, s)
+	}
+
+	// Values are only quoted when needed by people reading them
+	e := Entry{Time: testEntry.Time, Level: LevelInfo, Message: "hi", Fields: []Field{{"path", "/api"}, {"empty", ""}}}
+	exp = `10:20:30 INFO  hi path=/api empty=""` + "\n"
+	if s := string(ConsoleEncoder{NoColor: true}.Encode(&e)); s != exp {
+		t.Fatalf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestParseEncoder(t *testing.T) {
+	for s, exp := range map[string]Encoder{
+		"":        LogfmtEncoder{},
+		"logfmt":  LogfmtEncoder{},
+		"JSON":    JSONEncoder{},
+		"console": ConsoleEncoder{},
+	} {
+		if enc, err := ParseEncoder(s); err != nil || enc != exp {
+			t.Fatalf("expected %T for %q, got: %T (%v)", exp, s, enc, err)
+		}
+	}
+	if _, err := ParseEncoder("xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
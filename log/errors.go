@@ -0,0 +1,104 @@
+package log
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WithFields wraps err with key/values which are added to the line when the
+// error is logged, either as the message or as the value of a field. This
+// allows errors created deep in a call chain to carry structured context to
+// the place they are eventually logged. WithFields returns nil if err is nil.
+//
+// The wrapper is transparent to errors.Cause.
+func WithFields(err error, kvs ...KeyValues) error {
+	if err == nil {
+		return nil
+	}
+	fe := &FieldError{err: err}
+	for _, kv := range kvs {
+		fe.fields = append(fe.fields, kv.fields()...)
+	}
+	return fe
+}
+
+// FieldError is an error with key/values attached, see WithFields.
+type FieldError struct {
+	err    error
+	fields []Field
+}
+
+func (e *FieldError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the wrapped error.
+func (e *FieldError) Cause() error {
+	return e.err
+}
+
+// Unwrap returns the wrapped error.
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// Fields returns the key/values attached to the error.
+func (e *FieldError) Fields() []Field {
+	return e.fields
+}
+
+// errorFields returns the fields describing err when it is logged under key
+// k (not including k itself): a `<k>_cause` field when the cause differs from
+// err, followed by the fields of every FieldError in the chain, innermost
+// first.
+func errorFields(k string, err error) []Field {
+	var fs []Field
+
+	if cause := errors.Cause(err); cause != nil && cause.Error() != err.Error() {
+		fs = append(fs, Field{k + "_cause", cause.Error()})
+	}
+
+	var attached [][]Field
+	for e := err; e != nil; {
+		if fe, ok := e.(*FieldError); ok {
+			attached = append(attached, fe.fields)
+		}
+		c, ok := e.(causer)
+		if !ok {
+			break
+		}
+		e = c.Cause()
+	}
+	for i := len(attached) - 1; i >= 0; i-- {
+		fs = append(fs, attached[i]...)
+	}
+
+	return fs
+}
+
+// expandErrors follows each field with an error value by its errorFields. fs
+// is returned as is when there is nothing to add.
+func expandErrors(fs []Field) []Field {
+	var out []Field
+	for i, f := range fs {
+		err, ok := f.Value.(error)
+		if !ok {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		extra := errorFields(f.Key, err)
+		if len(extra) == 0 && out == nil {
+			continue
+		}
+		if out == nil {
+			out = append([]Field(nil), fs[:i]...)
+		}
+		out = append(out, f)
+		out = append(out, extra...)
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestErrorFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug)
+
+	err := errors.Wrap(WithFields(errors.Wrap(WithFields(errNotFound, M{"id": 7}), "query"), KV("table", "users")), "handler")
+	if errors.Cause(err) != errNotFound {
+		t.Fatal("expected WithFields to be transparent to errors.Cause")
+	}
+
+	l.Warn(err, M{"status": 404})
+	exp := ` msg="handler: query: not found" status=404 err="handler: query: not found" err_cause="not found" id=7 table=users` + "\n"
+	if !strings.HasSuffix(buf.String(), exp) {
+		t.Fatalf("expected suffix %q, got: %q", exp, buf.String())
+	}
+
+	buf.Reset()
+	l.Info("failed", M{"err": errors.Wrap(errNotFound, "lookup")})
+	exp = ` msg=failed err="lookup: not found" err_cause="not found"` + "\n"
+	if !strings.HasSuffix(buf.String(), exp) {
+		t.Fatalf("expected suffix %q, got: %q", exp, buf.String())
+	}
+
+	buf.Reset()
+	l.Warn(errNotFound)
+	exp = ` msg="not found" err="not found"` + "\n"
+	if !strings.HasSuffix(buf.String(), exp) {
+		t.Fatalf("expected suffix %q, got: %q", exp, buf.String())
+	}
+
+	if WithFields(nil, M{"a": 1}) != nil {
+		t.Fatal("expected nil")
+	}
+}
@@ -0,0 +1,322 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is appended to the path of rotated files. A `-<n>` counter
+// follows it when several files are rotated within the same millisecond.
+const backupTimeFormat = "20060102T150405.000"
+
+// OpenFile opens (or creates) a File for appending. Set the exported fields
+// to enable rotation before logging to it.
+func OpenFile(path string) (*File, error) {
+	f := &File{Path: path}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// File is an io.Writer which appends to a file and optionally rotates it.
+// Rotated files are renamed to `<Path>.<timestamp>` (with a `.gz` suffix when
+// compressed) in the same directory.
+//
+// For logrotate compatibility, use Reopen or ReopenOnSignal after the file
+// has been moved.
+type File struct {
+	// NOTE: These variables are not safe to change while writing.
+	Path string
+	// MaxSize is the size in bytes after which the file is rotated. Zero
+	// disables size based rotation.
+	MaxSize int64
+	// Interval rotates the file when the current time crosses a multiple of
+	// Interval (e.g. 24 * time.Hour rotates at midnight UTC). Zero disables
+	// time based rotation.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep. Zero keeps all of
+	// them.
+	MaxBackups int
+	// Compress gzips rotated files in the background.
+	Compress bool
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+	// bg tracks background compression and cleanup which run one at a time
+	// under bgMu
+	bg   sync.WaitGroup
+	bgMu sync.Mutex
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(len(p), time.Now()) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Flush commits the file's contents to stable storage.
+func (f *File) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+	return f.f.Sync()
+}
+
+// Rotate renames the current file and starts a new one.
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// Reopen closes the file and opens Path again. Use this after an external
+// tool such as logrotate has moved the file.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f != nil {
+		f.f.Close()
+		f.f = nil
+	}
+	return f.open()
+}
+
+// ReopenOnSignal calls Reopen each time one of sigs is received (SIGHUP if
+// none are given) until stop is called. Errors are logged with the default
+// Logger.
+func (f *File) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := f.Reopen(); err != nil {
+					std.Error(err, M{"path": f.Path})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// Close closes the file and waits for any background compression to finish.
+func (f *File) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.f != nil {
+		err = f.f.Close()
+		f.f = nil
+	}
+	f.mu.Unlock()
+
+	f.bg.Wait()
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.f = file
+	f.size = fi.Size()
+	f.opened = time.Now()
+	if f.size > 0 {
+		// Rotate a file left over from a previous interval
+		f.opened = fi.ModTime()
+	}
+	return nil
+}
+
+func (f *File) shouldRotate(n int, now time.Time) bool {
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(n) > f.MaxSize {
+		return true
+	}
+	return f.Interval > 0 && f.size > 0 && !now.Truncate(f.Interval).Equal(f.opened.Truncate(f.Interval))
+}
+
+// rotate must be called while holding f.mu with an open file.
+func (f *File) rotate() error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	f.f = nil
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.Path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.bg.Add(1)
+	go func(compress bool, maxBackups int) {
+		defer f.bg.Done()
+		f.bgMu.Lock()
+		defer f.bgMu.Unlock()
+
+		if compress {
+			if err := gzipFile(backup); err != nil {
+				std.Error(err, M{"path": backup})
+			}
+		}
+		if maxBackups > 0 {
+			f.removeBackups(maxBackups)
+		}
+	}(f.Compress, f.MaxBackups)
+
+	return nil
+}
+
+// backupName returns an unused path for a file rotated at t. Rename would
+// silently replace an existing backup so a counter is added if needed.
+func (f *File) backupName(t time.Time) string {
+	base := f.Path + "." + t.UTC().Format(backupTimeFormat)
+	name := base
+	for n := 1; exists(name) || exists(name+".gz"); n++ {
+		name = base + "-" + strconv.Itoa(n)
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// parseBackup returns the rotation time and counter from the suffix of a
+// rotated file, without the ".gz" extension.
+func parseBackup(s string) (time.Time, int, bool) {
+	var n int
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		var err error
+		if n, err = strconv.Atoi(s[i+1:]); err != nil || n < 1 {
+			return time.Time{}, 0, false
+		}
+		s = s[:i]
+	}
+	t, err := time.Parse(backupTimeFormat, s)
+	return t, n, err == nil
+}
+
+// removeBackups deletes all but the newest keep rotated files.
+func (f *File) removeBackups(keep int) {
+	matches, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path string
+		t    time.Time
+		n    int
+	}
+	var backups []backup
+	for _, m := range matches {
+		t, n, ok := parseBackup(strings.TrimSuffix(strings.TrimPrefix(m, f.Path+"."), ".gz"))
+		if ok {
+			backups = append(backups, backup{m, t, n})
+		}
+	}
+	if len(backups) <= keep {
+		return
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].t.Equal(backups[j].t) {
+			return backups[i].t.Before(backups[j].t)
+		}
+		return backups[i].n < backups[j].n
+	})
+	for _, b := range backups[:len(backups)-keep] {
+		os.Remove(b.path)
+	}
+}
+
+// gzipFile compresses path to path.gz and removes the original.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,209 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileRotateSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.MaxSize = 10
+	f.MaxBackups = 2
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		// Keep backup names unique
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	btys, _ := ioutil.ReadFile(path)
+	if exp := "dddddddd\n"; string(btys) != exp {
+		t.Fatalf("expected current file to contain %q, got: %q", exp, btys)
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got: %v", backups)
+	}
+	btys, _ = ioutil.ReadFile(backups[0])
+	if exp := "bbbbbbbb\n"; string(btys) != exp {
+		t.Fatalf("expected oldest kept backup to contain %q, got: %q", exp, btys)
+	}
+}
+
+func TestFileRotateCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Compress = true
+
+	l := New(f, LevelDebug)
+	l.Info("before")
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	f.Close()
+
+	backups, _ := filepath.Glob(path + ".*.gz")
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 compressed backup, got: %v", backups)
+	}
+	gz, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	btys, _ := ioutil.ReadAll(zr)
+	if !strings.Contains(string(btys), "msg=before") {
+		t.Fatalf("unexpected backup contents: %q", btys)
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("one\n"))
+	// Simulate logrotate
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("two\n"))
+
+	btys, _ := ioutil.ReadFile(path)
+	if exp := "two\n"; string(btys) != exp {
+		t.Fatalf("expected %q, got: %q", exp, btys)
+	}
+}
+
+func TestFileRotateInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Interval = time.Hour
+
+	f.Write([]byte("old\n"))
+	f.Write([]byte("same interval\n"))
+	if backups, _ := filepath.Glob(path + ".*"); len(backups) != 0 {
+		t.Fatalf("expected no backups, got: %v", backups)
+	}
+
+	f.opened = f.opened.Add(-time.Hour)
+	f.Write([]byte("new\n"))
+	if backups, _ := filepath.Glob(path + ".*"); len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got: %v", backups)
+	}
+}
+
+func TestFileRotateBurst(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	burst := func(name string, maxBackups int) string {
+		path := filepath.Join(dir, name)
+		f, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.MaxSize = 10
+		f.MaxBackups = maxBackups
+
+		// Many rotations happen within the same millisecond
+		for i := 0; i < 50; i++ {
+			if _, err := fmt.Fprintf(f, "line %03d\n", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := burst("all.log", 0)
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 49 {
+		t.Fatalf("expected 49 backups, got: %v", len(backups))
+	}
+	seen := map[string]bool{}
+	for _, b := range backups {
+		btys, _ := ioutil.ReadFile(b)
+		seen[string(btys)] = true
+	}
+	for i := 0; i < 49; i++ {
+		if s := fmt.Sprintf("line %03d\n", i); !seen[s] {
+			t.Fatalf("expected a backup containing %q", s)
+		}
+	}
+
+	path = burst("kept.log", 3)
+	backups, _ = filepath.Glob(path + ".*")
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 backups, got: %v", backups)
+	}
+	for _, b := range backups {
+		btys, _ := ioutil.ReadFile(b)
+		if s := string(btys); s < "line 046" {
+			t.Fatalf("expected only the newest backups to be kept, got: %q", s)
+		}
+	}
+}
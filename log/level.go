@@ -0,0 +1,153 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// inherit is stored in an AtomicLevel which follows its parent.
+const inherit = ^uint32(0)
+
+// AtomicLevel is a Level which is safe to change while logging, e.g. to
+// temporarily enable debug logging in a running service.
+type AtomicLevel struct {
+	// v is protected with atomic
+	v uint32
+	// parent is used while v is inherit. It is only set for named levels.
+	parent *AtomicLevel
+}
+
+// NewAtomicLevel creates an AtomicLevel set to lvl.
+func NewAtomicLevel(lvl Level) *AtomicLevel {
+	a := &AtomicLevel{}
+	a.Set(lvl)
+	return a
+}
+
+func (a *AtomicLevel) Get() Level {
+	for {
+		v := atomic.LoadUint32(&a.v)
+		if v != inherit || a.parent == nil {
+			return Level(v)
+		}
+		a = a.parent
+	}
+}
+
+func (a *AtomicLevel) Set(lvl Level) {
+	atomic.StoreUint32(&a.v, uint32(lvl))
+}
+
+func (a *AtomicLevel) String() string {
+	return a.Get().String()
+}
+
+// ServeHTTP allows the level to be read with a GET request and changed with a
+// PUT request. The level is sent and received as plain text, for example:
+//
+//	curl -X PUT -d debug http://localhost:8080/log/level
+//
+// The new level may also be given by a `level` query parameter.
+func (a *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		s := r.URL.Query().Get("level")
+		if s == "" {
+			btys, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s = string(btys)
+		}
+		lvl, err := ParseLevel(strings.TrimSpace(s))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if prev := a.Get(); prev != lvl {
+			// Log while the more verbose of both levels is in effect so that
+			// the change is recorded in either direction.
+			if lvl > prev {
+				a.Set(lvl)
+			}
+			std.Warn("log level changed", M{"from": prev, "to": lvl})
+			a.Set(lvl)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, a.Get())
+}
+
+// ReloadOnSignal sets the level using load each time one of sigs is received
+// (SIGHUP if none are given) until stop is called. Invalid levels are logged
+// and ignored.
+//
+// For GlobalLevel, load may return a spec including named levels such as
+// `info,web=debug`, see SetLevelSpec. Other levels only accept a single level.
+//
+// If load is nil the `LOG_LEVEL` env variable is used. Note that the env of a
+// running process is only changed through os.Setenv, so load will typically
+// read from a file or a config service.
+func (a *AtomicLevel) ReloadOnSignal(load func() string, sigs ...os.Signal) (stop func()) {
+	if load == nil {
+		load = func() string { return os.Getenv("LOG_LEVEL") }
+	}
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				s := strings.TrimSpace(load())
+				if err := a.setString(s); err != nil {
+					std.Warn("unable to reload log level", M{"level": s})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// setString sets the level from s, which may be a level spec for GlobalLevel.
+func (a *AtomicLevel) setString(s string) error {
+	if a == GlobalLevel {
+		return SetLevelSpec(s)
+	}
+
+	lvl, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	a.Set(lvl)
+	return nil
+}
+
+// setInherit makes a named level follow its parent again.
+func (a *AtomicLevel) setInherit() {
+	atomic.StoreUint32(&a.v, inherit)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAtomicLevelHTTP(t *testing.T) {
+	a := NewAtomicLevel(LevelInfo)
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if exp := "info\n"; rec.Body.String() != exp {
+		t.Fatalf("expected %q, got: %q", exp, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader("debug\n")))
+	if rec.Code != 200 || a.Get() != LevelDebug {
+		t.Fatalf("expected level to be set to debug, got: %v (status %v)", a.Get(), rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("PUT", "/?level=WARN", nil))
+	if rec.Code != 200 || a.Get() != LevelWarn {
+		t.Fatalf("expected level to be set to warn, got: %v (status %v)", a.Get(), rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader("loud")))
+	if rec.Code != 400 || a.Get() != LevelWarn {
+		t.Fatalf("expected bad request, got: %v (level %v)", rec.Code, a.Get())
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if rec.Code != 405 {
+		t.Fatalf("expected method not allowed, got: %v", rec.Code)
+	}
+}
+
+func TestAtomicLevelHTTPLogsChange(t *testing.T) {
+	var buf bytes.Buffer
+	setOutput(t, &buf)
+
+	orgLvl := GlobalLevel.Get()
+	defer GlobalLevel.Set(orgLvl)
+
+	for _, c := range []struct{ from, to Level }{
+		{LevelDebug, LevelError},
+		{LevelError, LevelDebug},
+	} {
+		buf.Reset()
+		GlobalLevel.Set(c.from)
+		GlobalLevel.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/", strings.NewReader(c.to.String())))
+
+		exp := fmt.Sprintf(` lvl=warning msg="log level changed" from=%s to=%s`, c.from, c.to)
+		if !strings.Contains(buf.String(), exp) {
+			t.Fatalf("expected %q, got: %q", exp, buf.String())
+		}
+	}
+}
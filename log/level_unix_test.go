@@ -0,0 +1,34 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnSignalSpec(t *testing.T) {
+	orgLvl := GlobalLevel.Get()
+	defer GlobalLevel.Set(orgLvl)
+	defer SetLevelSpec("")
+
+	named := Named("test_reload").Level()
+
+	stop := GlobalLevel.ReloadOnSignal(func() string { return "warn,test_reload=error" }, syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	for start := time.Now(); named.Get() != LevelError; time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("expected named level %s, got: %s", LevelError, named.Get())
+		}
+	}
+	if lvl := GlobalLevel.Get(); lvl != LevelWarn {
+		t.Fatalf("expected %s, got: %s", LevelWarn, lvl)
+	}
+}
@@ -4,12 +4,14 @@
 //
 // In practice, logs are commonly read by people so simple key-value logging is
 // used to provide a happy medium between human readable and machine parsable.
+// JSON and colorized console output are also available, see Encoder.
 package log
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -30,22 +32,100 @@ const (
 	LevelDebug
 )
 
-// GlobalLevel is set at init() time using the `LOG_LEVEL` env variable.
-// Misuse of this variable can lead to race conditions.
-var GlobalLevel Level
+// GlobalLevel is set at init() time using the `LOG_LEVEL` env variable. It is
+// the level of the default Logger and may be changed at any time. See
+// SetLevelSpec for the format of `LOG_LEVEL`.
+var GlobalLevel = NewAtomicLevel(LevelDebug)
+
+// std is the default Logger used by the package level functions.
+var std = &Logger{
+	out: &output{
+		sinks:    []Sink{{Writer: os.Stderr, Level: LevelDebug, Encoder: envEncoder()}},
+		redactor: DefaultRedactor,
+	},
+	level: GlobalLevel,
+}
 
 func init() {
-	GlobalLevel = stringToLevel(strings.ToLower(os.Getenv("LOG_LEVEL")))
-	log.SetFlags(0)
+	base, named, _ := parseLevelSpec(os.Getenv("LOG_LEVEL"))
+	applyLevelSpec(base, named)
+}
+
+func envEncoder() Encoder {
+	enc, err := ParseEncoder(os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		return LogfmtEncoder{}
+	}
+	return enc
+}
+
+// KeyValues are the key/values included in a line. They are implemented by M
+// and by the ordered pairs returned from KV.
+type KeyValues interface {
+	fields() []Field
 }
 
-// M is a convenience type for a map to save typing (pun intended).
+// M is a convenience type for a map to save typing (pun intended). Keys are
+// written in sorted order.
 type M map[string]interface{}
 
 func (kv M) String() string {
+	return fieldsToString(kv.fields())
+}
+
+func (kv M) fields() []Field {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fs := make([]Field, len(keys))
+	for i, k := range keys {
+		fs[i] = Field{k, kv[k]}
+	}
+	return fs
+}
+
+// Pairs are key/values which are written in the order they were given. See
+// KV.
+type Pairs []Field
+
+// KV creates Pairs from alternating keys and values, for example:
+// KV("b", 1, "a", 2) is written as `b=1 a=2`. Keys which are not strings are
+// formatted with fmt.Sprint. A trailing key without a value is given a nil
+// value.
+func KV(keysAndValues ...interface{}) Pairs {
+	ps := make(Pairs, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var v interface{}
+		if i+1 < len(keysAndValues) {
+			v = keysAndValues[i+1]
+		}
+		ps = append(ps, Field{fmt.Sprint(keysAndValues[i]), v})
+	}
+	return ps
+}
+
+func (ps Pairs) String() string {
+	return fieldsToString(ps)
+}
+
+func (ps Pairs) fields() []Field {
+	return ps
+}
+
+// Field is a single key/value.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// fieldsToString formats fields with a leading space before each pair.
+func fieldsToString(fs []Field) string {
 	var s string
-	for k, v := range kv {
-		s = fmt.Sprintf("%s %s", s, kvToString(k, v))
+	for _, f := range fs {
+		s = s + " " + kvToString(f.Key, f.Value)
 	}
 	return s
 }
@@ -72,73 +152,107 @@ func (level Level) String() string {
 	return "unknown"
 }
 
-func stringToLevel(s string) Level {
-	switch s {
+// ParseLevel converts a level name into a Level. It accepts the output of
+// Level.String as well as the shorthand "warn" and ignores case.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
 	case "panic":
-		return LevelPanic
+		return LevelPanic, nil
 	case "fatal":
-		return LevelFatal
+		return LevelFatal, nil
 	case "error":
-		return LevelError
-	case "warn":
-		return LevelWarn
+		return LevelError, nil
+	case "warn", "warning":
+		return LevelWarn, nil
 	case "info":
-		return LevelInfo
-	default:
-		return LevelDebug
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
 	}
+	return LevelDebug, fmt.Errorf("unknown level %q", s)
 }
 
-func Debug(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelDebug {
-		prnt(LevelDebug, msg, kvs...)
-	}
+func stringToLevel(s string) Level {
+	lvl, _ := ParseLevel(s)
+	return lvl
 }
 
-func Info(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelInfo {
-		prnt(LevelInfo, msg, kvs...)
+// Default returns the Logger used by the package level functions such as
+// Info. Its level is GlobalLevel.
+func Default() *Logger {
+	return std
+}
 
-	}
+// SetOutput sets the destination of the default Logger. It defaults to
+// os.Stderr.
+func SetOutput(w io.Writer) {
+	std.SetOutput(w)
 }
 
-func Warn(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelWarn {
-		prnt(LevelWarn, msg, kvs...)
-	}
+// SetEncoder sets the Encoder of the default Logger. It is set at init() time
+// using the `LOG_FORMAT` env variable, see ParseEncoder.
+func SetEncoder(enc Encoder) {
+	std.SetEncoder(enc)
 }
 
-func Error(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelError {
-		prnt(LevelError, msg, kvs...)
-	}
+// SetSinks replaces the outputs of the default Logger, see Logger.SetSinks.
+// NOTE: GlobalLevel is made as verbose as the most verbose sink if needed,
+// overriding a less verbose level set by `LOG_LEVEL` or SetLevelSpec.
+func SetSinks(sinks ...Sink) {
+	std.SetSinks(sinks...)
 }
 
-func Fatal(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelFatal {
-		prnt(LevelFatal, msg, kvs...)
-		os.Exit(1)
-	}
+// SetRedactor changes the Redactor of the default Logger, see
+// Logger.SetRedactor.
+func SetRedactor(r *Redactor) {
+	std.SetRedactor(r)
 }
 
-func Panic(msg interface{}, kvs ...M) {
-	if GlobalLevel >= LevelPanic {
-		prnt(LevelPanic, msg, kvs...)
-		panic(msg)
-	}
+// SetCaller adds the location of the logging call to every line written by
+// the default Logger, see Logger.SetCaller.
+func SetCaller(enabled bool) {
+	std.SetCaller(enabled)
 }
 
-func prnt(lvl Level, msg interface{}, kvs ...M) {
-	var kvStr string
-	for _, kv := range kvs {
-		kvStr = kvStr + kv.String()
-	}
-	log.Printf("%s %s %s%s\n",
-		kvToString("ts", time.Now().Format(time.RFC3339)),
-		kvToString("lvl", lvl),
-		kvToString("msg", msg),
-		kvStr,
-	)
+// SetLimiter limits the lines written by the default Logger, see
+// Logger.SetLimiter.
+func SetLimiter(lim Limiter, summary time.Duration) {
+	std.SetLimiter(lim, summary)
+}
+
+// Flush flushes the default Logger, see Logger.Flush.
+func Flush() error {
+	return std.Flush()
+}
+
+// With returns a child of the default Logger which includes the given
+// key/values in every line.
+func With(kvs ...KeyValues) *Logger {
+	return std.With(kvs...)
+}
+
+func Debug(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelDebug, msg, kvs...)
+}
+
+func Info(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelInfo, msg, kvs...)
+}
+
+func Warn(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelWarn, msg, kvs...)
+}
+
+func Error(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelError, msg, kvs...)
+}
+
+func Fatal(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelFatal, msg, kvs...)
+}
+
+func Panic(msg interface{}, kvs ...KeyValues) {
+	std.prnt(LevelPanic, msg, kvs...)
 }
 
 func kvToString(k string, v interface{}) string {
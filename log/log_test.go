@@ -1,10 +1,101 @@
 package log
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
 	"testing"
 )
 
+// setOutput sends the lines of the default Logger to w as logfmt, regardless
+// of LOG_FORMAT, until the test finishes.
+func setOutput(t *testing.T, w io.Writer) {
+	sinks := std.Sinks()
+	SetOutput(w)
+	SetEncoder(LogfmtEncoder{})
+
+	t.Cleanup(func() {
+		std.out.mu.Lock()
+		std.out.sinks = sinks
+		std.out.mu.Unlock()
+	})
+}
+
 func TestInfo(t *testing.T) {
-	// TODO
-	t.Fatal("write this")
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo, M{"app": "test"})
+
+	l.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("expected debug to be ignored, got: %q", buf.String())
+	}
+
+	l.Info("hello world", M{"n": 1})
+	ln := buf.String()
+	if !strings.HasPrefix(ln, "ts=") || !strings.HasSuffix(ln, ` lvl=info msg="hello world" app=test n=1`+"\n") {
+		t.Fatalf("unexpected line: %q", ln)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	var buf bytes.Buffer
+	setOutput(t, &buf)
+
+	orgLvl := GlobalLevel.Get()
+	GlobalLevel.Set(LevelWarn)
+	defer GlobalLevel.Set(orgLvl)
+
+	Info("hidden")
+	Warn("shown")
+
+	if !strings.HasSuffix(buf.String(), " lvl=warning msg=shown\n") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	parent := New(&buf, LevelDebug, M{"app": "test"})
+	child := parent.With(M{"user_id": 7, "request_id": "abc"}).With(M{"b": 1, "a": 2})
+
+	child.Info("hi", M{"n": 1})
+	if !strings.HasSuffix(buf.String(), " msg=hi app=test request_id=abc user_id=7 a=2 b=1 n=1\n") {
+		t.Fatalf("unexpected line: %q", buf.String())
+	}
+
+	buf.Reset()
+	parent.Info("hi")
+	if !strings.HasSuffix(buf.String(), " msg=hi app=test\n") {
+		t.Fatalf("expected parent to be unaffected, got: %q", buf.String())
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != Default() {
+		t.Fatal("expected the default logger from an empty context")
+	}
+
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(&buf, LevelDebug).With(M{"request_id": "abc"}))
+
+	InfoCtx(ctx, "hi")
+	if !strings.HasSuffix(buf.String(), " msg=hi request_id=abc\n") {
+		t.Fatalf("unexpected line: %q", buf.String())
+	}
+}
+
+func TestKeyValuesOrder(t *testing.T) {
+	if exp, s := ` a=1 b=2 c="x y"`, (M{"c": "x y", "b": 2, "a": 1}).String(); s != exp {
+		t.Fatalf("expected sorted %q, got: %q", exp, s)
+	}
+	if exp, s := ` c=1 a=2 b="<nil>"`, KV("c", 1, "a", 2, "b").String(); s != exp {
+		t.Fatalf("expected ordered %q, got: %q", exp, s)
+	}
+
+	var buf bytes.Buffer
+	New(&buf, LevelDebug).With(KV("z", 1)).Info("hi", M{"b": 1, "a": 1}, KV("y", 2, "x", 3))
+	if !strings.HasSuffix(buf.String(), " msg=hi z=1 a=1 b=1 y=2 x=3\n") {
+		t.Fatalf("unexpected line: %q", buf.String())
+	}
 }
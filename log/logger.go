@@ -0,0 +1,219 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Logger writes lines to an io.Writer. Each Logger has its own level and may
+// have key/values which are included in every line. A Logger is safe for
+// concurrent use.
+//
+// When the message is an error, it is also added as an `err` field followed
+// by an `err_cause` field if errors.Cause differs from the error, along with
+// any key/values attached with WithFields. Errors passed as field values are
+// expanded the same way, e.g. M{"err": err} may add `err_cause`. When an error
+// logged at the error, fatal or panic level carries a stack trace from
+// github.com/pkg/errors, a `stack` field is added to the line.
+type Logger struct {
+	out    *output
+	level  *AtomicLevel
+	fields []Field
+}
+
+// output is shared between a Logger and its children.
+type output struct {
+	mu       sync.Mutex
+	sinks    []Sink
+	lim      *limiting
+	redactor *Redactor
+	// caller is protected with atomic
+	caller uint32
+}
+
+// write encodes and writes e to each sink which allows its level. It must be
+// called while holding o.mu.
+func (o *output) write(e *Entry) {
+	for _, s := range o.sinks {
+		if e.Level <= s.Level {
+			s.Writer.Write(s.Encoder.Encode(e))
+		}
+	}
+}
+
+// New creates a Logger which writes lines at or above the given level to w.
+// Any key/values given are included in every line. Lines are encoded with
+// LogfmtEncoder unless changed with SetEncoder.
+func New(w io.Writer, lvl Level, kvs ...KeyValues) *Logger {
+	l := &Logger{
+		out: &output{
+			sinks:    []Sink{{Writer: w, Level: LevelDebug, Encoder: LogfmtEncoder{}}},
+			redactor: DefaultRedactor,
+		},
+		level: NewAtomicLevel(lvl),
+	}
+	return l.with(kvs)
+}
+
+// With returns a child Logger which includes the given key/values in every
+// line, ahead of any key/values passed to an individual call. Keys are
+// written in the order With was called.
+//
+// The child shares its level and output with l.
+func (l *Logger) With(kvs ...KeyValues) *Logger {
+	return l.with(kvs)
+}
+
+func (l *Logger) with(kvs []KeyValues) *Logger {
+	child := *l
+	child.fields = append([]Field(nil), l.fields...)
+	for _, kv := range kvs {
+		child.fields = append(child.fields, expandErrors(kv.fields())...)
+	}
+	return &child
+}
+
+// SetOutput changes the destination of l and any Loggers created from it with
+// With. For a Logger created with Tee, every sink is changed.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	for i := range l.out.sinks {
+		l.out.sinks[i].Writer = w
+	}
+}
+
+// SetEncoder changes the Encoder of l and any Loggers created from it with
+// With. For a Logger created with Tee, every sink is changed.
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	for i := range l.out.sinks {
+		l.out.sinks[i].Encoder = enc
+	}
+}
+
+// SetCaller adds a `caller=dir/file.go:123` field, with the location of the
+// logging call, to every line written by l and any Loggers created from it
+// with With.
+func (l *Logger) SetCaller(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&l.out.caller, v)
+}
+
+// Flush writes any pending summaries of dropped lines (see SetLimiter) and
+// then, for each output with a `Flush() error` method (e.g. AsyncWriter),
+// waits for buffered lines to be written. The first error is returned.
+func (l *Logger) Flush() error {
+	l.out.mu.Lock()
+	if lim := l.out.lim; lim != nil && lim.timer != nil && lim.timer.Stop() {
+		l.out.writeDropped(lim)
+	}
+	sinks := l.out.sinks
+	l.out.mu.Unlock()
+
+	var err error
+	for _, s := range sinks {
+		f, ok := s.Writer.(interface {
+			Flush() error
+		})
+		if !ok {
+			continue
+		}
+		if ferr := f.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Level returns the level of the Logger which may be changed while logging.
+func (l *Logger) Level() *AtomicLevel {
+	return l.level
+}
+
+func (l *Logger) Debug(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelDebug, msg, kvs...)
+}
+
+func (l *Logger) Info(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelInfo, msg, kvs...)
+}
+
+func (l *Logger) Warn(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelWarn, msg, kvs...)
+}
+
+func (l *Logger) Error(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelError, msg, kvs...)
+}
+
+// Fatal logs, flushes and then calls `os.Exit(1)`.
+func (l *Logger) Fatal(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelFatal, msg, kvs...)
+}
+
+// Panic logs, flushes and then panics with msg.
+func (l *Logger) Panic(msg interface{}, kvs ...KeyValues) {
+	l.prnt(LevelPanic, msg, kvs...)
+}
+
+// prnt writes a line if lvl is enabled and then exits or panics for the
+// fatal and panic levels.
+func (l *Logger) prnt(lvl Level, msg interface{}, kvs ...KeyValues) {
+	if l.level.Get() < lvl {
+		return
+	}
+
+	e := Entry{
+		Time:    time.Now(),
+		Level:   lvl,
+		Message: msg,
+		Fields:  l.fields,
+	}
+
+	var pre, post []Field
+	if atomic.LoadUint32(&l.out.caller) == 1 {
+		pre = append(pre, Field{"caller", caller(2)})
+	}
+	if err, ok := msg.(error); ok {
+		post = append(post, Field{"err", err})
+		post = append(post, errorFields("err", err)...)
+		if st := errorStack(err); st != "" && lvl <= LevelError {
+			post = append(post, Field{"stack", st})
+		}
+	}
+	if len(pre) > 0 || len(kvs) > 0 || len(post) > 0 {
+		// Copy so the bound fields are never appended to
+		e.Fields = append(pre, l.fields...)
+		for _, kv := range kvs {
+			e.Fields = append(e.Fields, expandErrors(kv.fields())...)
+		}
+		e.Fields = append(e.Fields, post...)
+	}
+
+	l.out.mu.Lock()
+	if r := l.out.redactor; r != nil {
+		e.Message = r.RedactMessage(e.Message)
+		e.Fields = r.Redact(e.Fields)
+	}
+	if l.out.allow(&e) {
+		l.out.write(&e)
+	}
+	l.out.mu.Unlock()
+
+	switch lvl {
+	case LevelFatal:
+		l.Flush()
+		os.Exit(1)
+	case LevelPanic:
+		l.Flush()
+		panic(msg)
+	}
+}
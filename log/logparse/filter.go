@@ -0,0 +1,349 @@
+package logparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/upgear/go-kit/log"
+)
+
+// Filter is a predicate over a Line.
+type Filter func(Line) bool
+
+// Compile parses a filter expression such as:
+//
+//	lvl>=warn AND status>=500 AND path~"^/api"
+//
+// An expression is made up of comparisons joined with AND, OR and NOT
+// (case-insensitive) and grouped with parentheses. A comparison is a key, an
+// operator and a value. Values containing whitespace, parentheses, quotes or
+// operator characters must be Go quoted strings.
+//
+// Operators are =, !=, <, <=, >, >=, ~ (regexp match) and !~ (regexp does not
+// match). A key on its own matches lines which contain that key.
+//
+// The keys `lvl` and `ts` compare as a log.Level and an RFC3339 time
+// respectively. A more severe level is greater, so `lvl>=warn` matches
+// warning, error, fatal and panic lines. The key `msg` refers to the message.
+// Any other key refers to Line.Values and compares numerically when both sides
+// are numbers, otherwise as strings. Comparisons against a missing key never
+// match.
+//
+// An empty expression matches every line.
+func Compile(expr string) (Filter, error) {
+	p := &parser{lex: lexer{src: expr}}
+	p.next()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind == tokEOF {
+		return func(Line) bool { return true }, nil
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return f, nil
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+func MustCompile(expr string) Filter {
+	f, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	val  string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.val)
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos == len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, val: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, val: ")", pos: start}, nil
+	case c == '"':
+		end, err := quotedEnd(l.src, l.pos)
+		if err != nil {
+			return token{}, err
+		}
+		s, err := strconv.Unquote(l.src[l.pos:end])
+		if err != nil {
+			return token{}, &SyntaxError{Offset: start, Msg: "invalid quoted string"}
+		}
+		l.pos = end
+		return token{kind: tokString, val: s, pos: start}, nil
+	case isOpChar(c):
+		for l.pos < len(l.src) && isOpChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokOp, val: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for l.pos < len(l.src) && !isSpace(l.src[l.pos]) && !isOpChar(l.src[l.pos]) &&
+		!strings.ContainsRune(`()"`, rune(l.src[l.pos])) {
+		l.pos++
+	}
+	return token{kind: tokWord, val: l.src[start:l.pos], pos: start}, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isOpChar(c byte) bool {
+	return strings.IndexByte("=!<>~", c) >= 0
+}
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &SyntaxError{Offset: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	return p.tok.kind == tokWord && strings.EqualFold(p.tok.val, kw)
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ln Line) bool { return l(ln) || right(ln) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ln Line) bool { return l(ln) && right(ln) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Filter, error) {
+	switch {
+	case p.err != nil:
+		return nil, p.err
+	case p.isKeyword("not"):
+		p.next()
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(ln Line) bool { return !f(ln) }, nil
+	case p.tok.kind == tokLParen:
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected ) but found %s", p.tok)
+		}
+		p.next()
+		return f, nil
+	case p.tok.kind == tokWord || p.tok.kind == tokString:
+		return p.parseComparison()
+	}
+	return nil, p.errorf("unexpected %s", p.tok)
+}
+
+func (p *parser) parseComparison() (Filter, error) {
+	key := p.tok.val
+	p.next()
+
+	if p.tok.kind != tokOp {
+		if p.err != nil {
+			return nil, p.err
+		}
+		return func(ln Line) bool {
+			_, ok := lookup(ln, key)
+			return ok
+		}, nil
+	}
+
+	op := p.tok
+	p.next()
+	if p.tok.kind != tokWord && p.tok.kind != tokString {
+		return nil, p.errorf("expected value after %s but found %s", op, p.tok)
+	}
+	val := p.tok
+	p.next()
+
+	if op.val == "~" || op.val == "!~" {
+		re, err := regexp.Compile(val.val)
+		if err != nil {
+			return nil, &SyntaxError{Offset: val.pos, Msg: err.Error()}
+		}
+		want := op.val == "~"
+		return func(ln Line) bool {
+			v, ok := lookup(ln, key)
+			return ok && re.MatchString(v) == want
+		}, nil
+	}
+
+	match, ok := opMatchers[op.val]
+	if !ok {
+		return nil, &SyntaxError{Offset: op.pos, Msg: fmt.Sprintf("unknown operator %s", op)}
+	}
+
+	switch key {
+	case "lvl":
+		lvl, err := log.ParseLevel(val.val)
+		if err != nil {
+			return nil, &SyntaxError{Offset: val.pos, Msg: err.Error()}
+		}
+		// Lower levels are more severe
+		return func(ln Line) bool { return match(int(lvl) - int(ln.Level)) }, nil
+	case "ts":
+		t, err := parseTime(val.val)
+		if err != nil {
+			return nil, &SyntaxError{Offset: val.pos, Msg: fmt.Sprintf("invalid time %s", val)}
+		}
+		return func(ln Line) bool {
+			switch {
+			case ln.Time.Before(t):
+				return match(-1)
+			case ln.Time.After(t):
+				return match(1)
+			}
+			return match(0)
+		}, nil
+	}
+
+	return func(ln Line) bool {
+		v, ok := lookup(ln, key)
+		return ok && match(compareValues(v, val.val))
+	}, nil
+}
+
+// opMatchers map an operator to a function which interprets the result of a
+// three-way comparison.
+var opMatchers = map[string]func(int) bool{
+	"=":  func(c int) bool { return c == 0 },
+	"!=": func(c int) bool { return c != 0 },
+	"<":  func(c int) bool { return c < 0 },
+	"<=": func(c int) bool { return c <= 0 },
+	">":  func(c int) bool { return c > 0 },
+	">=": func(c int) bool { return c >= 0 },
+}
+
+func lookup(ln Line, key string) (string, bool) {
+	switch key {
+	case "msg":
+		return ln.Message, true
+	case "lvl":
+		return ln.Level.String(), true
+	case "ts":
+		return ln.Time.Format(time.RFC3339), true
+	}
+	v, ok := ln.Values[key]
+	return v, ok
+}
+
+func compareValues(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,67 @@
+package logparse_test
+
+import (
+	"testing"
+
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+func TestCompile(t *testing.T) {
+	ln := logparse.ParseLine(`ts="2017-06-01T10:20:30Z" lvl=error msg="request failed" status=503 path=/api/users dur=1.5`)
+
+	cases := map[string]bool{
+		``:                           true,
+		`lvl>=warn`:                  true,
+		`lvl>error`:                  false,
+		`lvl=error`:                  true,
+		`lvl<=info`:                  false,
+		`status>=500`:                true,
+		`status>=1000`:               false,
+		`status=503.0`:               true,
+		`dur<2`:                      true,
+		`path~"^/api"`:               true,
+		`path!~"^/api"`:              false,
+		`msg~failed`:                 true,
+		`msg="request failed"`:       true,
+		`missing!=1`:                 false,
+		`status`:                     true,
+		`NOT missing`:                true,
+		`ts>="2017-06-01T00:00:00Z"`: true,
+		`ts<2017-06-01`:              false,
+		`lvl>=warn AND status>=500 AND path~"^/api"`:   true,
+		`lvl=debug or (status=503 and not path~users)`: false,
+		`lvl=debug OR NOT (status=503 AND path~users)`: false,
+		`lvl=debug OR status=503`:                      true,
+	}
+
+	for expr, exp := range cases {
+		f, err := logparse.Compile(expr)
+		if err != nil {
+			t.Fatalf("unable to compile %q: %s", expr, err)
+		}
+		if got := f(ln); got != exp {
+			t.Fatalf("expected %q to be %v, got: %v", expr, exp, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		`lvl>=`,
+		`lvl>=loud`,
+		`ts>now`,
+		`(status=500`,
+		`status=500)`,
+		`status=>500`,
+		`path~"("`,
+		`msg="unterminated`,
+		`status=500 AND`,
+		`"unterminated`,
+		`"\q"`,
+		`status=500 "bad`,
+	} {
+		if _, err := logparse.Compile(expr); err == nil {
+			t.Fatalf("expected error compiling %q", expr)
+		}
+	}
+}
@@ -1,18 +1,166 @@
+// Package logparse reads back lines written by the log package.
+//
+// A line is a series of whitespace-separated key=value pairs which always
+// starts with the `ts`, `lvl` and `msg` keys. Values which contain characters
+// other than letters, digits, '-' or '.' are Go quoted strings.
 package logparse
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/upgear/go-kit/log"
 )
 
+// Line is a single parsed log line.
 type Line struct {
 	Time    time.Time
 	Level   log.Level
 	Message string
-	Values  map[string]string
+	// Values holds every pair other than `ts`, `lvl` and `msg`.
+	Values map[string]string
+	// Keys holds the keys of Values in the order they appeared in the line.
+	Keys []string
 }
 
+// SyntaxError describes why a line could not be parsed.
+type SyntaxError struct {
+	// Offset is the byte offset in the line where the error occurred.
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("logparse: %s at offset %v", e.Msg, e.Offset)
+}
+
+// ParseLine parses a line written by the log package. Malformed lines result
+// in whatever could be parsed before the error, see Parse for details.
 func ParseLine(ln string) Line {
-	return Line{}
+	l, _ := Parse(ln)
+	return l
+}
+
+// Parse parses a line written by the log package. A *SyntaxError is returned
+// if the line is not made up of key=value pairs or if the `ts`, `lvl` or `msg`
+// keys are missing or invalid.
+func Parse(ln string) (Line, error) {
+	var (
+		l                     Line
+		hasTS, hasLvl, hasMsg bool
+	)
+
+	ln = strings.TrimRight(ln, "\r\n")
+
+	for i := 0; ; {
+		for i < len(ln) && ln[i] == ' ' {
+			i++
+		}
+		if i == len(ln) {
+			break
+		}
+
+		start := i
+		k, v, next, err := parsePair(ln, i)
+		if err != nil {
+			return l, err
+		}
+		i = next
+
+		switch k {
+		case "ts":
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return l, &SyntaxError{Offset: start, Msg: fmt.Sprintf("invalid ts %q", v)}
+			}
+			l.Time, hasTS = t, true
+		case "lvl":
+			lvl, err := log.ParseLevel(v)
+			if err != nil {
+				return l, &SyntaxError{Offset: start, Msg: fmt.Sprintf("invalid lvl %q", v)}
+			}
+			l.Level, hasLvl = lvl, true
+		case "msg":
+			l.Message, hasMsg = v, true
+		default:
+			if l.Values == nil {
+				l.Values = make(map[string]string)
+			}
+			if _, dup := l.Values[k]; !dup {
+				l.Keys = append(l.Keys, k)
+			}
+			l.Values[k] = v
+		}
+	}
+
+	switch {
+	case !hasTS:
+		return l, &SyntaxError{Offset: 0, Msg: `missing "ts" key`}
+	case !hasLvl:
+		return l, &SyntaxError{Offset: 0, Msg: `missing "lvl" key`}
+	case !hasMsg:
+		return l, &SyntaxError{Offset: 0, Msg: `missing "msg" key`}
+	}
+
+	return l, nil
+}
+
+// parsePair reads a single key=value pair starting at offset i. It returns
+// the offset immediately after the value.
+func parsePair(ln string, i int) (string, string, int, error) {
+	start := i
+	for i < len(ln) && ln[i] != '=' {
+		if ln[i] == ' ' || ln[i] == '"' {
+			return "", "", i, &SyntaxError{Offset: i, Msg: "expected '=' after key"}
+		}
+		i++
+	}
+	if i == len(ln) {
+		return "", "", i, &SyntaxError{Offset: i, Msg: "expected '=' after key"}
+	}
+	if i == start {
+		return "", "", i, &SyntaxError{Offset: i, Msg: "empty key"}
+	}
+	k := ln[start:i]
+	i++ // Skip '='
+
+	if i < len(ln) && ln[i] == '"' {
+		end, err := quotedEnd(ln, i)
+		if err != nil {
+			return "", "", end, err
+		}
+		v, err := strconv.Unquote(ln[i:end])
+		if err != nil {
+			return "", "", i, &SyntaxError{Offset: i, Msg: "invalid quoted value"}
+		}
+		if end < len(ln) && ln[end] != ' ' {
+			return "", "", end, &SyntaxError{Offset: end, Msg: "expected space after quoted value"}
+		}
+		return k, v, end, nil
+	}
+
+	vStart := i
+	for i < len(ln) && ln[i] != ' ' {
+		if ln[i] == '"' {
+			return "", "", i, &SyntaxError{Offset: i, Msg: "unexpected quote in value"}
+		}
+		i++
+	}
+	return k, ln[vStart:i], i, nil
+}
+
+// quotedEnd returns the offset just past the closing quote of the quoted
+// string starting at offset i.
+func quotedEnd(ln string, i int) (int, error) {
+	for j := i + 1; j < len(ln); j++ {
+		switch ln[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return len(ln), &SyntaxError{Offset: i, Msg: "unterminated quoted value"}
 }
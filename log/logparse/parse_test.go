@@ -0,0 +1,78 @@
+package logparse_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+func TestParse(t *testing.T) {
+	l, err := logparse.Parse(`ts="2017-06-01T10:20:30Z" lvl=warning msg="a \"quoted\"\nmessage" status=500 path="/api/x y" empty=""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := time.Date(2017, 6, 1, 10, 20, 30, 0, time.UTC); !l.Time.Equal(exp) {
+		t.Fatalf("expected time %s, got: %s", exp, l.Time)
+	}
+	if l.Level != log.LevelWarn {
+		t.Fatalf("expected level %s, got: %s", log.LevelWarn, l.Level)
+	}
+	if exp := "a \"quoted\"\nmessage"; l.Message != exp {
+		t.Fatalf("expected message %q, got: %q", exp, l.Message)
+	}
+
+	exp := map[string]string{"status": "500", "path": "/api/x y", "empty": ""}
+	if len(l.Values) != len(exp) {
+		t.Fatalf("expected values %v, got: %v", exp, l.Values)
+	}
+	for k, v := range exp {
+		if l.Values[k] != v {
+			t.Fatalf("expected %s=%q, got: %q", k, v, l.Values[k])
+		}
+	}
+	if len(l.Keys) != 3 || l.Keys[0] != "status" || l.Keys[2] != "empty" {
+		t.Fatalf("expected keys in order of appearance, got: %v", l.Keys)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, ln := range []string{
+		``,
+		`hello world`,
+		`ts="2017-06-01T10:20:30Z" lvl=info`,
+		`ts=yesterday lvl=info msg=hi`,
+		`ts="2017-06-01T10:20:30Z" lvl=loud msg=hi`,
+		`ts="2017-06-01T10:20:30Z" lvl=info msg="unterminated`,
+		`ts="2017-06-01T10:20:30Z" lvl=info msg="a"b`,
+		`ts="2017-06-01T10:20:30Z" lvl=info msg=hi =x`,
+	} {
+		if _, err := logparse.Parse(ln); err == nil {
+			t.Fatalf("expected error parsing %q", ln)
+		} else if _, ok := err.(*logparse.SyntaxError); !ok {
+			t.Fatalf("expected *SyntaxError, got: %T", err)
+		}
+	}
+}
+
+func TestParseLineRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	log.New(&buf, log.LevelDebug).Error("ut oh\t\"bad\"", log.M{"status": 503, "path": "/a b", "ok": "yes"})
+
+	l := logparse.ParseLine(buf.String())
+	if l.Level != log.LevelError {
+		t.Fatalf("expected level %s, got: %s", log.LevelError, l.Level)
+	}
+	if exp := "ut oh\t\"bad\""; l.Message != exp {
+		t.Fatalf("expected message %q, got: %q", exp, l.Message)
+	}
+	if l.Values["status"] != "503" || l.Values["path"] != "/a b" || l.Values["ok"] != "yes" {
+		t.Fatalf("unexpected values: %v", l.Values)
+	}
+	if time.Since(l.Time) > time.Minute {
+		t.Fatalf("unexpected time: %s", l.Time)
+	}
+}
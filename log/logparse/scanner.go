@@ -0,0 +1,127 @@
+package logparse
+
+import (
+	"bufio"
+	"io"
+)
+
+// Scanner reads Lines from an io.Reader one at a time. Blank lines and lines
+// which were not written by the log package (e.g. output from other processes
+// sharing the same stream) are skipped.
+//
+// Unlike bufio.Scanner, there is no limit on the length of a line unless
+// MaxLineSize is set.
+type Scanner struct {
+	// MaxLineSize is the maximum number of bytes in a line. Longer lines are
+	// skipped without being buffered in full. Zero means no limit.
+	MaxLineSize int
+
+	r       *bufio.Reader
+	buf     []byte
+	line    Line
+	text    string
+	skipped int
+	err     error
+}
+
+// NewScanner returns a Scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReader(r)}
+}
+
+// Scan advances to the next valid line, which will then be available through
+// the Line and Text methods. It returns false when the end of the input is
+// reached or a read error occurs.
+func (s *Scanner) Scan() bool {
+	for s.err == nil {
+		raw, tooLong, err := s.readLine()
+		if err != nil && (err != io.EOF || (len(raw) == 0 && !tooLong)) {
+			s.err = err
+			return false
+		}
+
+		if len(raw) == 0 && !tooLong {
+			continue
+		}
+		if tooLong {
+			s.skipped++
+			continue
+		}
+
+		text := string(raw)
+		l, perr := Parse(text)
+		if perr != nil {
+			s.skipped++
+			continue
+		}
+
+		s.line, s.text = l, text
+		return true
+	}
+	return false
+}
+
+// Line returns the most recent Line read by Scan.
+func (s *Scanner) Line() Line {
+	return s.line
+}
+
+// Text returns the raw text (without the line ending) of the most recent Line
+// read by Scan.
+func (s *Scanner) Text() string {
+	return s.text
+}
+
+// Skipped returns the number of non-blank lines which have been skipped
+// because they could not be parsed or exceeded MaxLineSize.
+func (s *Scanner) Skipped() int {
+	return s.skipped
+}
+
+// Err returns the first non-EOF error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// readLine returns the next line without its line ending. The returned slice
+// is only valid until the next call. If the line exceeds MaxLineSize the rest
+// of it is discarded and tooLong is reported.
+func (s *Scanner) readLine() (raw []byte, tooLong bool, err error) {
+	s.buf = s.buf[:0]
+
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+
+		if !tooLong {
+			if s.MaxLineSize > 0 && len(s.buf)+len(trimEOL(chunk)) > s.MaxLineSize {
+				tooLong = true
+				s.buf = s.buf[:0]
+			} else if len(s.buf) == 0 && err == nil {
+				// Common case: the whole line fit in the reader's buffer.
+				return trimEOL(chunk), false, nil
+			} else {
+				s.buf = append(s.buf, chunk...)
+			}
+		}
+
+		if err != bufio.ErrBufferFull {
+			if tooLong {
+				return nil, true, err
+			}
+			return trimEOL(s.buf), false, err
+		}
+	}
+}
+
+func trimEOL(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+	}
+	if n := len(b); n > 0 && b[n-1] == '\r' {
+		b = b[:n-1]
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package logparse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+func TestScanner(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	input := strings.Join([]string{
+		`ts="2017-06-01T10:20:30Z" lvl=info msg=one`,
+		``,
+		`panic: something unrelated`,
+		`ts="2017-06-01T10:20:31Z" lvl=debug msg=` + long + "\r",
+		`   `,
+		`ts="2017-06-01T10:20:32Z" lvl=error msg=three`,
+	}, "\n")
+
+	s := logparse.NewScanner(strings.NewReader(input))
+
+	var msgs []string
+	for s.Scan() {
+		msgs = append(msgs, s.Line().Message)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msgs) != 3 || msgs[0] != "one" || msgs[1] != long || msgs[2] != "three" {
+		t.Fatalf("unexpected messages: %.40q", msgs)
+	}
+	if exp := 2; s.Skipped() != exp {
+		t.Fatalf("expected %v skipped lines, got: %v", exp, s.Skipped())
+	}
+}
+
+func TestScannerMaxLineSize(t *testing.T) {
+	input := `ts="2017-06-01T10:20:30Z" lvl=info msg=` + strings.Repeat("x", 10000) + "\n" +
+		`ts="2017-06-01T10:20:31Z" lvl=info msg=short` + "\n"
+
+	s := logparse.NewScanner(strings.NewReader(input))
+	s.MaxLineSize = 100
+
+	if !s.Scan() {
+		t.Fatalf("expected a line, err: %v", s.Err())
+	}
+	if exp := "short"; s.Line().Message != exp {
+		t.Fatalf("expected message %q, got: %.40q", exp, s.Line().Message)
+	}
+	if s.Scan() {
+		t.Fatal("expected no more lines")
+	}
+	if exp := 1; s.Skipped() != exp {
+		t.Fatalf("expected %v skipped lines, got: %v", exp, s.Skipped())
+	}
+}
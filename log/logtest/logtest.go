@@ -0,0 +1,152 @@
+// Package logtest captures lines written by the log package so that tests can
+// make assertions about them.
+//
+//	func TestHandler(t *testing.T) {
+//		rec := logtest.Capture(t)
+//		...
+//		rec.AssertLogged(log.LevelError, "ut oh", log.M{"status": 500})
+//	}
+package logtest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logparse"
+)
+
+// New creates a Logger at the given level which writes to a new Recorder.
+func New(t testing.TB, lvl log.Level) (*log.Logger, *Recorder) {
+	rec := &Recorder{t: t}
+	return log.New(rec, lvl), rec
+}
+
+// Capture redirects the default Logger, and the Loggers derived from it such
+// as those created by log.Named, to a new Recorder at the debug level. Named
+// levels set by `LOG_LEVEL` or log.SetLevelSpec are cleared so that every line
+// is captured. The previous outputs and levels are restored when the test
+// finishes.
+//
+// Tests using Capture must not run in parallel.
+func Capture(t testing.TB) *Recorder {
+	rec := &Recorder{t: t}
+
+	sinks := log.Default().Sinks()
+	spec := log.LevelSpec()
+	log.SetSinks(log.Sink{Writer: rec, Level: log.LevelDebug})
+	log.SetLevelSpec("debug")
+
+	t.Cleanup(func() {
+		log.SetSinks(sinks...)
+		log.SetLevelSpec(spec)
+	})
+
+	return rec
+}
+
+// Recorder is an io.Writer which parses each line written to it. It expects
+// the default (logfmt) encoding. It is safe for concurrent use.
+type Recorder struct {
+	t testing.TB
+
+	mu      sync.Mutex
+	partial []byte
+	lines   []logparse.Line
+}
+
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		ln := string(r.partial[:i])
+		r.partial = r.partial[i+1:]
+
+		l, err := logparse.Parse(ln)
+		if err != nil {
+			r.t.Errorf("logtest: unable to parse line %q: %s", ln, err)
+			continue
+		}
+		r.lines = append(r.lines, l)
+	}
+
+	return len(p), nil
+}
+
+// Lines returns the lines recorded so far.
+func (r *Recorder) Lines() []logparse.Line {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]logparse.Line(nil), r.lines...)
+}
+
+// Reset discards the lines recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = nil
+}
+
+// Logged reports whether a line was recorded with the given level, message
+// and fields. Lines may contain more fields than those given. Field values
+// are compared after formatting them with fmt.Sprint.
+func (r *Recorder) Logged(lvl log.Level, msg string, fields log.M) bool {
+	for _, l := range r.Lines() {
+		if l.Level == lvl && l.Message == msg && hasFields(l, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails the test unless a matching line was recorded, see
+// Logged.
+func (r *Recorder) AssertLogged(lvl log.Level, msg string, fields log.M) {
+	r.t.Helper()
+	if !r.Logged(lvl, msg, fields) {
+		r.t.Errorf("logtest: expected a line with lvl=%s msg=%q%s, got:\n%s", lvl, msg, fields, r)
+	}
+}
+
+// NoErrors fails the test if any line was recorded at the error level or
+// above.
+func (r *Recorder) NoErrors() {
+	r.t.Helper()
+	for _, l := range r.Lines() {
+		if l.Level <= log.LevelError {
+			r.t.Errorf("logtest: unexpected %s line: %q", l.Level, l.Message)
+		}
+	}
+}
+
+// String returns a summary of the recorded lines, one per line.
+func (r *Recorder) String() string {
+	var b strings.Builder
+	for _, l := range r.Lines() {
+		fmt.Fprintf(&b, "\tlvl=%s msg=%q", l.Level, l.Message)
+		for _, k := range l.Keys {
+			fmt.Fprintf(&b, " %s=%q", k, l.Values[k])
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func hasFields(l logparse.Line, fields log.M) bool {
+	for k, v := range fields {
+		got, ok := l.Values[k]
+		if !ok || got != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package logtest_test
+
+import (
+	"testing"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logtest"
+)
+
+func TestRecorder(t *testing.T) {
+	l, rec := logtest.New(t, log.LevelInfo)
+
+	l.Debug("hidden")
+	l.Warn("slow request", log.M{"path": "/a b", "dur": 1.5})
+
+	rec.AssertLogged(log.LevelWarn, "slow request", log.M{"path": "/a b"})
+	rec.AssertLogged(log.LevelWarn, "slow request", log.M{"dur": 1.5})
+	rec.NoErrors()
+
+	if rec.Logged(log.LevelDebug, "hidden", nil) {
+		t.Fatal("expected debug line to be filtered")
+	}
+	if rec.Logged(log.LevelWarn, "slow request", log.M{"path": "/other"}) {
+		t.Fatal("expected mismatched fields not to match")
+	}
+
+	rec.Reset()
+	if n := len(rec.Lines()); n != 0 {
+		t.Fatalf("expected no lines after reset, got: %v", n)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	log.Named("logtest").Error("ut oh", log.M{"status": 500})
+
+	rec.AssertLogged(log.LevelError, "ut oh", log.M{"status": 500})
+}
+
+func TestCaptureNamedOverride(t *testing.T) {
+	spec := log.LevelSpec()
+	defer log.SetLevelSpec(spec)
+
+	if err := log.SetLevelSpec("info,logtest_quiet=error"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("capture", func(t *testing.T) {
+		rec := logtest.Capture(t)
+		log.Named("logtest_quiet").Debug("shh")
+		rec.AssertLogged(log.LevelDebug, "shh", nil)
+	})
+
+	if exp := "info,logtest_quiet=error"; log.LevelSpec() != exp {
+		t.Fatalf("expected levels to be restored to %q, got: %q", exp, log.LevelSpec())
+	}
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	namedMu sync.Mutex
+	// namedLevels holds the level of every name given to Named or included
+	// in a level spec.
+	namedLevels = map[string]*AtomicLevel{}
+)
+
+// Named returns a child of the default Logger with its own level, so that,
+// for example, a single package can log at the debug level. The level follows
+// GlobalLevel unless it has been overridden in `LOG_LEVEL`, by SetLevelSpec or
+// by setting it directly:
+//
+//	var logger = log.Named("web")
+//	...
+//	log.Named("web").Level().Set(log.LevelDebug)
+//
+// Loggers with the same name share their level.
+func Named(name string) *Logger {
+	l := *std
+	l.level = namedLevel(name)
+	return &l
+}
+
+func namedLevel(name string) *AtomicLevel {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	a, ok := namedLevels[name]
+	if !ok {
+		a = &AtomicLevel{v: inherit, parent: GlobalLevel}
+		namedLevels[name] = a
+	}
+	return a
+}
+
+// SetLevelSpec sets GlobalLevel and the levels of named Loggers (see Named)
+// from a comma separated spec such as:
+//
+//	info,web=debug,circuit=warn
+//
+// An element without a name sets GlobalLevel, which is left as is when there
+// is none. Named Loggers which are not included follow GlobalLevel. Nothing is
+// changed if the spec is invalid.
+func SetLevelSpec(spec string) error {
+	base, named, err := parseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+	applyLevelSpec(base, named)
+	return nil
+}
+
+// LevelSpec returns the current levels in the format accepted by
+// SetLevelSpec: GlobalLevel followed by every overridden named level, sorted by
+// name. It can be used to restore the levels later.
+func LevelSpec() string {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	elems := []string{GlobalLevel.String()}
+	for name, a := range namedLevels {
+		if atomic.LoadUint32(&a.v) != inherit {
+			elems = append(elems, name+"="+a.String())
+		}
+	}
+	sort.Strings(elems[1:])
+
+	return strings.Join(elems, ",")
+}
+
+// parseLevelSpec returns the base level (or nil) and named levels of a
+// spec. On error, everything valid is still returned.
+func parseLevelSpec(spec string) (*Level, map[string]Level, error) {
+	var (
+		base     *Level
+		named    = map[string]Level{}
+		firstErr error
+	)
+
+	for _, elem := range strings.Split(spec, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+
+		name, s := "", elem
+		if i := strings.IndexByte(elem, '='); i >= 0 {
+			name, s = strings.TrimSpace(elem[:i]), strings.TrimSpace(elem[i+1:])
+		}
+
+		lvl, err := ParseLevel(s)
+		if err == nil && name == "" && strings.Contains(elem, "=") {
+			err = fmt.Errorf("missing name in %q", elem)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if name == "" {
+			base = &lvl
+		} else {
+			named[name] = lvl
+		}
+	}
+
+	return base, named, firstErr
+}
+
+func applyLevelSpec(base *Level, named map[string]Level) {
+	if base != nil {
+		GlobalLevel.Set(*base)
+	}
+
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	for name, a := range namedLevels {
+		if _, ok := named[name]; !ok {
+			a.setInherit()
+		}
+	}
+	for name, lvl := range named {
+		a, ok := namedLevels[name]
+		if !ok {
+			a = &AtomicLevel{parent: GlobalLevel}
+			namedLevels[name] = a
+		}
+		a.Set(lvl)
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	setOutput(t, &buf)
+
+	orgLvl := GlobalLevel.Get()
+	defer GlobalLevel.Set(orgLvl)
+	defer SetLevelSpec("")
+
+	if err := SetLevelSpec("warn, test_web=debug ,test_circuit=error"); err != nil {
+		t.Fatal(err)
+	}
+
+	web, circuit, other := Named("test_web"), Named("test_circuit"), Named("test_other")
+	web.Debug("web")
+	circuit.Warn("circuit")
+	other.Info("other")
+	other.Warn("other")
+	Info("default")
+
+	if exp := "msg=web\nmsg=other\n"; stripTS(buf.String()) != exp {
+		t.Fatalf("expected %q, got: %q", exp, stripTS(buf.String()))
+	}
+	if exp := "warning,test_circuit=error,test_web=debug"; LevelSpec() != exp {
+		t.Fatalf("expected spec %q, got: %q", exp, LevelSpec())
+	}
+
+	// Named levels which are no longer in the spec follow GlobalLevel again
+	buf.Reset()
+	if err := SetLevelSpec("error"); err != nil {
+		t.Fatal(err)
+	}
+	web.Warn("web")
+	web.Error("web")
+	if exp := "msg=web\n"; stripTS(buf.String()) != exp {
+		t.Fatalf("expected %q, got: %q", exp, stripTS(buf.String()))
+	}
+
+	for _, spec := range []string{"loud", "web=loud", "=debug"} {
+		if err := SetLevelSpec(spec); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+// stripTS removes everything but the message from each line.
+func stripTS(s string) string {
+	var out string
+	for _, ln := range strings.SplitAfter(s, "\n") {
+		if i := strings.Index(ln, "msg="); i >= 0 {
+			out += ln[i:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the values of sensitive fields.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactor is used by every Logger unless changed with SetRedactor.
+var DefaultRedactor = &Redactor{
+	Keys: []string{
+		"password", "passwd", "secret", "authorization", "cookie",
+		"token", "access_token", "refresh_token", "api_key", "apikey", "ssn",
+	},
+	Values: []*regexp.Regexp{
+		// Bearer and basic authorization header values. A credential-shaped
+		// token is required so that ordinary text such as "basic setup" is
+		// left alone.
+		regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9\-._~+/]{16,}=*`),
+	},
+}
+
+// Redactor replaces the values of sensitive fields with Redacted before they
+// are encoded. Parts of the message matching Values are replaced as well.
+type Redactor struct {
+	// NOTE: These variables are not safe to change while logging.
+	// Keys are field names, compared case-insensitively. A key also matches
+	// field names which contain it as whole words separated by '_', '-' or
+	// '.', e.g. "token" matches "auth_token" and "api_key" matches
+	// "x-api-key".
+	Keys []string
+	// Values are matched against the formatted value of every field and
+	// against the formatted message.
+	Values []*regexp.Regexp
+}
+
+// Redact returns fs with sensitive values replaced. fs is never modified; a
+// copy is made if anything needs to be redacted.
+func (r *Redactor) Redact(fs []Field) []Field {
+	var out []Field
+	for i, f := range fs {
+		if !r.sensitive(f) {
+			continue
+		}
+		if out == nil {
+			out = append([]Field(nil), fs...)
+		}
+		out[i].Value = Redacted
+	}
+	if out == nil {
+		return fs
+	}
+	return out
+}
+
+// RedactMessage returns msg formatted as a string with every match of Values
+// replaced. msg is returned as is if nothing matches.
+func (r *Redactor) RedactMessage(msg interface{}) interface{} {
+	if len(r.Values) == 0 {
+		return msg
+	}
+
+	s := fmt.Sprint(msg)
+	redacted := s
+	for _, re := range r.Values {
+		redacted = re.ReplaceAllString(redacted, Redacted)
+	}
+	if redacted == s {
+		return msg
+	}
+	return redacted
+}
+
+func (r *Redactor) sensitive(f Field) bool {
+	if len(r.Keys) > 0 {
+		name := "_" + normalizeKey(f.Key) + "_"
+		for _, k := range r.Keys {
+			if strings.Contains(name, "_"+normalizeKey(k)+"_") {
+				return true
+			}
+		}
+	}
+	if len(r.Values) == 0 {
+		return false
+	}
+	v := fmt.Sprint(f.Value)
+	for _, re := range r.Values {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeKey lowercases k and replaces word separators with '_'.
+func normalizeKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '.':
+			return '_'
+		}
+		return unicode.ToLower(r)
+	}, k)
+}
+
+// SetRedactor changes the Redactor of l and any Loggers created from it with
+// With. A nil Redactor disables redaction.
+func (l *Logger) SetRedactor(r *Redactor) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.redactor = r
+}
@@ -0,0 +1,229 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether a line should be written. It is consulted for the
+// debug, info, warning and error levels; fatal and panic lines are always
+// written. See Sampler and RateLimiter.
+type Limiter interface {
+	Allow(e *Entry) bool
+}
+
+// entryKey identifies lines with the same level and message.
+func entryKey(e *Entry) string {
+	return e.Level.String() + "\x00" + fmt.Sprint(e.Message)
+}
+
+// NewSampler creates a Sampler which, per Interval, allows the first lines
+// with a given level and message and then every thereafter-th line.
+func NewSampler(interval time.Duration, first, thereafter int) *Sampler {
+	return &Sampler{
+		Interval:   interval,
+		First:      first,
+		Thereafter: thereafter,
+	}
+}
+
+// Sampler is a Limiter which counts lines by level and message. Within each
+// Interval the First lines are allowed and then every Thereafter-th line.
+type Sampler struct {
+	// NOTE: These variables are not safe to change while logging.
+	Interval time.Duration
+	First    int
+	// Thereafter can be zero to drop every line after First.
+	Thereafter int
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+func (s *Sampler) Allow(e *Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); s.counts == nil || now.Sub(s.start) >= s.Interval {
+		s.counts = make(map[string]int)
+		s.start = now
+	}
+
+	k := entryKey(e)
+	s.counts[k]++
+	n := s.counts[k]
+
+	if n <= s.First {
+		return true
+	}
+	return s.Thereafter > 0 && (n-s.First)%s.Thereafter == 0
+}
+
+// NewRateLimiter creates a RateLimiter allowing rate lines per second for each
+// level and message, with bursts of up to burst lines.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Rate:  rate,
+		Burst: burst,
+	}
+}
+
+// RateLimiter is a Limiter which maintains a token bucket per key.
+type RateLimiter struct {
+	// NOTE: These variables are not safe to change while logging.
+	// Rate is the number of lines per second allowed for each key.
+	Rate float64
+	// Burst is the maximum number of lines allowed at once for each key.
+	Burst int
+	// Key groups lines. It defaults to the level and message when nil. For
+	// example, it could return a `user_id` field to limit lines per user.
+	Key func(e *Entry) string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// maxBuckets is the number of keys a RateLimiter tracks before discarding
+// buckets which have refilled.
+const maxBuckets = 10000
+
+func (r *RateLimiter) Allow(e *Entry) bool {
+	key := entryKey
+	if r.Key != nil {
+		key = r.Key
+	}
+	k := key(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.buckets == nil {
+		r.buckets = make(map[string]*bucket)
+	}
+
+	b, ok := r.buckets[k]
+	if !ok {
+		if len(r.buckets) >= maxBuckets {
+			r.prune(now)
+		}
+		b = &bucket{tokens: float64(r.Burst), last: now}
+		r.buckets[k] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * r.Rate
+	if max := float64(r.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune removes buckets which would be full by now.
+func (r *RateLimiter) prune(now time.Time) {
+	for k, b := range r.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*r.Rate >= float64(r.Burst) {
+			delete(r.buckets, k)
+		}
+	}
+}
+
+// limiting tracks the lines dropped by a Limiter so that they can be
+// summarized. It is protected by the mutex of the output it belongs to.
+type limiting struct {
+	lim     Limiter
+	summary time.Duration
+	dropped map[dropKey]int
+	timer   *time.Timer
+}
+
+type dropKey struct {
+	lvl Level
+	msg string
+}
+
+// allow consults the Limiter and schedules a summary for dropped lines. It
+// must be called while holding o.mu.
+func (o *output) allow(e *Entry) bool {
+	if o.lim == nil || e.Level < LevelError || o.lim.lim.Allow(e) {
+		return true
+	}
+
+	if o.lim.dropped == nil {
+		o.lim.dropped = make(map[dropKey]int)
+	}
+	o.lim.dropped[dropKey{e.Level, fmt.Sprint(e.Message)}]++
+
+	if o.lim.timer == nil {
+		lim := o.lim
+		o.lim.timer = time.AfterFunc(lim.summary, func() { o.summarize(lim) })
+	}
+	return false
+}
+
+func (o *output) summarize(lim *limiting) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.writeDropped(lim)
+}
+
+// writeDropped writes a "dropped N lines" line, at the level of the dropped
+// lines, for each level and message which had lines dropped. It must be
+// called while holding o.mu.
+func (o *output) writeDropped(lim *limiting) {
+	keys := make([]dropKey, 0, len(lim.dropped))
+	for k := range lim.dropped {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].lvl != keys[j].lvl {
+			return keys[i].lvl < keys[j].lvl
+		}
+		return keys[i].msg < keys[j].msg
+	})
+
+	for _, k := range keys {
+		n := lim.dropped[k]
+		o.write(&Entry{
+			Time:    time.Now(),
+			Level:   k.lvl,
+			Message: fmt.Sprintf("dropped %v lines", n),
+			Fields:  KV("dropped", n, "sampled_msg", k.msg),
+		})
+	}
+
+	lim.dropped = nil
+	lim.timer = nil
+}
+
+// SetLimiter limits the lines written by l and any Loggers created from it
+// with With. Dropped lines are counted by level and message, and summarized
+// with a "dropped N lines" line after the summary interval. A nil Limiter
+// removes any limit.
+func (l *Logger) SetLimiter(lim Limiter, summary time.Duration) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+
+	// Summarize now rather than losing track of what was dropped
+	if old := l.out.lim; old != nil && old.timer != nil && old.timer.Stop() {
+		l.out.writeDropped(old)
+	}
+	if lim == nil {
+		l.out.lim = nil
+		return
+	}
+	l.out.lim = &limiting{lim: lim, summary: summary}
+}
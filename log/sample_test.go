@@ -0,0 +1,74 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer which is safe to read while a summary is
+// being written from a timer.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSampler(t *testing.T) {
+	var buf syncBuffer
+	l := New(&buf, LevelDebug)
+	l.SetLimiter(NewSampler(time.Hour, 2, 3), 10*time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		l.Info("hot")
+		l.Warn("other")
+	}
+
+	// Lines 1, 2, 5 and 8 are allowed
+	if n := strings.Count(buf.String(), "msg=hot"); n != 4 {
+		t.Fatalf("expected 4 sampled lines, got: %v", n)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	out := buf.String()
+	if !strings.Contains(out, `lvl=info msg="dropped 6 lines" dropped=6 sampled_msg=hot`) {
+		t.Fatalf("expected a summary of dropped info lines, got: %q", out)
+	}
+	if !strings.Contains(out, `lvl=warning msg="dropped 6 lines" dropped=6 sampled_msg=other`) {
+		t.Fatalf("expected a summary of dropped warning lines, got: %q", out)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	r := NewRateLimiter(1, 3)
+	r.Key = func(e *Entry) string { return fmt.Sprint(e.Fields[0].Value) }
+
+	var allowed int
+	for i := 0; i < 10; i++ {
+		if r.Allow(&Entry{Level: LevelInfo, Message: "x", Fields: KV("user_id", 1)}) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Fatalf("expected a burst of 3, got: %v", allowed)
+	}
+
+	if !r.Allow(&Entry{Level: LevelInfo, Message: "x", Fields: KV("user_id", 2)}) {
+		t.Fatal("expected a separate bucket for a different key")
+	}
+}
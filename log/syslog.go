@@ -0,0 +1,292 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// syslogSeverities maps a Level to a syslog severity.
+var syslogSeverities = map[Level]int{
+	LevelPanic: 1, // Alert
+	LevelFatal: 2, // Critical
+	LevelError: 3, // Error
+	LevelWarn:  4, // Warning
+	LevelInfo:  6, // Informational
+	LevelDebug: 7, // Debug
+}
+
+// NewSyslog dials a syslog server (see DialSyslog) and returns a Logger which
+// writes lines at or above the given level to it using a SyslogEncoder with
+// the given app name.
+func NewSyslog(network, addr, app string, lvl Level) (*Logger, error) {
+	w, err := DialSyslog(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	l := New(w, lvl)
+	l.SetEncoder(&SyslogEncoder{AppName: app})
+	return l, nil
+}
+
+// SyslogEncoder formats entries as RFC 5424 syslog messages. The fields of
+// an Entry are written as parameters of a single structured-data element.
+//
+// Unlike the other encoders, no trailing newline is written, see
+// SyslogWriter.
+type SyslogEncoder struct {
+	// Facility code, e.g. 16 for local0. Zero defaults to 1 (user).
+	Facility int
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// AppName defaults to the name of the executable.
+	AppName string
+	// SDID is the ID of the structured-data element. It defaults to
+	// "kit@32473".
+	SDID string
+
+	once sync.Once
+	// header is the part of the message after the timestamp which does not
+	// change between entries.
+	header string
+}
+
+func (s *SyslogEncoder) Encode(e *Entry) []byte {
+	s.once.Do(s.init)
+
+	facility := s.Facility
+	if facility == 0 {
+		facility = 1
+	}
+	sev, ok := syslogSeverities[e.Level]
+	if !ok {
+		sev = 7
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s ", facility*8+sev, e.Time.Format("2006-01-02T15:04:05.000000Z07:00"), s.header)
+
+	var params int
+	for _, f := range e.Fields {
+		name := sdName(f.Key)
+		if name == "" {
+			continue
+		}
+		if params == 0 {
+			b.WriteByte('[')
+			b.WriteString(s.SDID)
+		}
+		params++
+		b.WriteByte(' ')
+		b.WriteString(name)
+		b.WriteString(`="`)
+		writeSDValue(&b, fmt.Sprint(f.Value))
+		b.WriteByte('"')
+	}
+	if params == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteByte(']')
+	}
+
+	b.WriteByte(' ')
+	fmt.Fprint(&b, e.Message)
+
+	return b.Bytes()
+}
+
+func (s *SyslogEncoder) init() {
+	if s.Hostname == "" {
+		s.Hostname, _ = os.Hostname()
+	}
+	if s.AppName == "" {
+		s.AppName = filepath.Base(os.Args[0])
+	}
+	if s.SDID == "" {
+		s.SDID = "kit@32473"
+	}
+	s.header = fmt.Sprintf("%s %s %d -", syslogHeaderField(s.Hostname, 255), syslogHeaderField(s.AppName, 48), os.Getpid())
+}
+
+// syslogHeaderField replaces an empty value with the nil value "-" and
+// truncates it to max characters.
+func syslogHeaderField(s string, max int) string {
+	s = sdName(s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// sdName converts a key into a valid SD-NAME: printable ASCII other than
+// '=', ' ', ']' and '"', at most 32 characters.
+func sdName(k string) string {
+	btys := []byte(k)
+	for i, c := range btys {
+		if c <= 32 || c >= 127 || c == '=' || c == ']' || c == '"' {
+			btys[i] = '_'
+		}
+	}
+	if len(btys) > 32 {
+		btys = btys[:32]
+	}
+	return string(btys)
+}
+
+// writeSDValue escapes '"', '\' and ']' in a PARAM-VALUE.
+func writeSDValue(b *bytes.Buffer, v string) {
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '"', '\\', ']':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(v[i])
+	}
+}
+
+// ErrSyslogDisconnected is returned by SyslogWriter while it waits to
+// reconnect after a failure.
+var ErrSyslogDisconnected = errors.New("log: syslog disconnected")
+
+const (
+	syslogMinBackoff = 100 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+)
+
+// DialSyslog connects to a syslog server. The network may be "udp", "tcp",
+// "unix" or "unixgram" (and their variations accepted by net.Dial), e.g.
+// DialSyslog("unixgram", "/dev/log").
+func DialSyslog(network, addr string) (*SyslogWriter, error) {
+	w := &SyslogWriter{network: network, addr: addr}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// SyslogWriter sends each Write as a single syslog message. Messages sent
+// over stream connections (TCP and unix sockets) are framed with octet
+// counting as described in RFC 6587.
+//
+// The connection is re-established if a write fails. If that fails too, writes
+// return ErrSyslogDisconnected, dropping the message, until the next attempt
+// to reconnect. The delay between attempts doubles up to 30 seconds so that a
+// missing server does not stall every logging call.
+type SyslogWriter struct {
+	// Timeout limits how long connecting and each write may take. Zero means
+	// 1 second.
+	// NOTE: This variable is not safe to change while writing.
+	Timeout time.Duration
+
+	network, addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	// backoff is the delay before the next attempt to reconnect
+	backoff time.Duration
+	// retryAt is when the next attempt to reconnect may be made
+	retryAt time.Time
+}
+
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if err := w.write(p); err == nil {
+			return len(p), nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if time.Now().Before(w.retryAt) {
+		return 0, ErrSyslogDisconnected
+	}
+
+	// Try once more with a new connection
+	if err := w.connect(); err != nil {
+		w.failed()
+		return 0, err
+	}
+	if err := w.write(p); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.failed()
+		return 0, err
+	}
+	w.backoff = 0
+	return len(p), nil
+}
+
+// failed delays the next attempt to reconnect. It must be called while
+// holding w.mu.
+func (w *SyslogWriter) failed() {
+	switch {
+	case w.backoff == 0:
+		w.backoff = syslogMinBackoff
+	case w.backoff < syslogMaxBackoff:
+		w.backoff *= 2
+		if w.backoff > syslogMaxBackoff {
+			w.backoff = syslogMaxBackoff
+		}
+	}
+	w.retryAt = time.Now().Add(w.backoff)
+}
+
+func (w *SyslogWriter) timeout() time.Duration {
+	if w.Timeout > 0 {
+		return w.Timeout
+	}
+	return time.Second
+}
+
+// Close closes the connection.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *SyslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.addr, w.timeout())
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *SyslogWriter) write(p []byte) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout())); err != nil {
+		return err
+	}
+
+	switch w.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		_, err := w.conn.Write(p)
+		return err
+	}
+
+	frame := make([]byte, 0, len(p)+8)
+	frame = strconv.AppendInt(frame, int64(len(p)), 10)
+	frame = append(frame, ' ')
+	frame = append(frame, p...)
+	_, err := w.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,174 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogEncoder(t *testing.T) {
+	enc := &SyslogEncoder{Facility: 16, Hostname: "host", AppName: "app"}
+	e := Entry{
+		Time:    time.Date(2017, 6, 1, 10, 20, 30, 0, time.UTC),
+		Level:   LevelWarn,
+		Message: "ut oh",
+		Fields:  KV("path", `/a "b"]`, "bad key", 1),
+	}
+
+	exp := `<132>1 2017-06-01T10:20:30.000000Z host app ` + strconv.Itoa(os.Getpid()) +
+		` - [kit@32473 path="/a \"b\"\]" bad_key="1"] ut oh`
+	if s := string(enc.Encode(&e)); s != exp {
+		t.Fatalf("expected %q, got: %q", exp, s)
+	}
+
+	e.Fields = nil
+	if s := string(enc.Encode(&e)); !strings.HasSuffix(s, " - - ut oh") {
+		t.Fatalf("expected nil structured data, got: %q", s)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	l, err := NewSyslog("udp", conn.LocalAddr().String(), "app", LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Error("boom", M{"status": 500})
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, ` [kit@32473 status="500"] boom`) {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			msgs <- string(msg)
+		}
+	}()
+
+	l, err := NewSyslog("tcp", ln.Addr().String(), "app", LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("one")
+	l.Info("two")
+
+	for _, exp := range []string{"one", "two"} {
+		select {
+		case msg := <-msgs:
+			if !strings.HasPrefix(msg, "<14>1 ") || !strings.HasSuffix(msg, " - "+exp) {
+				t.Fatalf("unexpected message: %q", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+	}
+}
+
+func TestSyslogDisconnected(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := &SyslogWriter{network: "tcp", addr: addr}
+	if _, err := w.Write([]byte("one")); err == nil || err == ErrSyslogDisconnected {
+		t.Fatalf("expected a dial error, got: %v", err)
+	}
+
+	// Writes fail fast until it is time to reconnect
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write([]byte("two")); err != ErrSyslogDisconnected {
+			t.Fatalf("expected %v, got: %v", ErrSyslogDisconnected, err)
+		}
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("expected writes to return immediately, took: %s", d)
+	}
+
+	w.retryAt = time.Time{}
+	w.Write([]byte("three"))
+	if w.backoff != 2*syslogMinBackoff {
+		t.Fatalf("expected backoff to double to %s, got: %s", 2*syslogMinBackoff, w.backoff)
+	}
+}
+
+func TestSyslogWriteTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// Accept but never read
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			<-done
+		}
+	}()
+
+	w, err := DialSyslog("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Timeout = 50 * time.Millisecond
+
+	// Without a deadline, writes would block once the buffers are full
+	msg := make([]byte, 1<<20)
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		w.Write(msg)
+		if d := time.Since(start); d > time.Second {
+			t.Fatalf("expected write %v to time out, took: %s", i, d)
+		}
+	}
+}
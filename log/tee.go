@@ -0,0 +1,69 @@
+package log
+
+import "io"
+
+// Sink is a destination for lines with its own minimum level and Encoder.
+// See Tee.
+//
+// NOTE: Level must always be set. Its zero value is LevelPanic so a Sink
+// without a Level only receives panics.
+type Sink struct {
+	Writer io.Writer
+	// Level is the least severe level written to Writer. It has no default,
+	// see the note above.
+	Level Level
+	// Encoder defaults to LogfmtEncoder when nil.
+	Encoder Encoder
+}
+
+// Tee creates a Logger which writes each line to every sink whose Level
+// allows it, for example:
+//
+//	l := log.Tee(
+//		log.Sink{Writer: file, Level: log.LevelDebug},
+//		log.Sink{Writer: os.Stderr, Level: log.LevelWarn, Encoder: log.ConsoleEncoder{}},
+//		log.Sink{Writer: syslog, Level: log.LevelError, Encoder: &log.SyslogEncoder{}},
+//	)
+//
+// The level of the Logger is the most verbose sink level.
+func Tee(sinks ...Sink) *Logger {
+	l := &Logger{
+		out:   &output{redactor: DefaultRedactor},
+		level: NewAtomicLevel(LevelPanic),
+	}
+	l.SetSinks(sinks...)
+	return l
+}
+
+// SetSinks replaces the outputs of l and any Loggers created from it with
+// With. The level of l applies before each sink's Level, so it is made as
+// verbose as the most verbose sink if needed. It is otherwise left as is, e.g.
+// debug set by `LOG_LEVEL` is kept when every sink is at the warning level.
+func (l *Logger) SetSinks(sinks ...Sink) {
+	sinks = append([]Sink(nil), sinks...)
+
+	lvl := LevelPanic
+	for i, s := range sinks {
+		if s.Encoder == nil {
+			sinks[i].Encoder = LogfmtEncoder{}
+		}
+		if s.Level > lvl {
+			lvl = s.Level
+		}
+	}
+
+	l.out.mu.Lock()
+	l.out.sinks = sinks
+	l.out.mu.Unlock()
+
+	if lvl > l.level.Get() {
+		l.level.Set(lvl)
+	}
+}
+
+// Sinks returns a copy of the outputs of l.
+func (l *Logger) Sinks() []Sink {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	return append([]Sink(nil), l.out.sinks...)
+}
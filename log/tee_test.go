@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	var file, stderr, collector bytes.Buffer
+	l := Tee(
+		Sink{Writer: &file, Level: LevelDebug},
+		Sink{Writer: &stderr, Level: LevelWarn, Encoder: ConsoleEncoder{NoColor: true}},
+		Sink{Writer: &collector, Level: LevelError, Encoder: JSONEncoder{}},
+	)
+
+	if l.Level().Get() != LevelDebug {
+		t.Fatalf("expected the most verbose sink level, got: %s", l.Level())
+	}
+
+	l.Debug("d")
+	l.Warn("w")
+	l.Error("e")
+
+	if n := strings.Count(file.String(), "\n"); n != 3 || !strings.Contains(file.String(), "lvl=debug msg=d") {
+		t.Fatalf("expected 3 logfmt lines, got: %q", file.String())
+	}
+	if n := strings.Count(stderr.String(), "\n"); n != 2 || !strings.Contains(stderr.String(), "WARN  w") {
+		t.Fatalf("expected 2 console lines, got: %q", stderr.String())
+	}
+	if n := strings.Count(collector.String(), "\n"); n != 1 || !strings.Contains(collector.String(), `"msg":"e"`) {
+		t.Fatalf("expected 1 JSON line, got: %q", collector.String())
+	}
+}
+
+func TestSetSinksKeepsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelDebug)
+
+	l.SetSinks(Sink{Writer: &buf, Level: LevelWarn})
+	if lvl := l.Level().Get(); lvl != LevelDebug {
+		t.Fatalf("expected %s to be kept, got: %s", LevelDebug, lvl)
+	}
+
+	l.Level().Set(LevelError)
+	l.SetSinks(Sink{Writer: &buf, Level: LevelInfo})
+	if lvl := l.Level().Get(); lvl != LevelInfo {
+		t.Fatalf("expected %s for the info sink, got: %s", LevelInfo, lvl)
+	}
+}
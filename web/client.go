@@ -1,15 +1,18 @@
 package web
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/upgear/go-kit/circuit"
+	"github.com/upgear/go-kit/log"
 	"github.com/upgear/go-kit/retry"
 )
 
@@ -18,14 +21,18 @@ var Err5XX = errors.New("5XX server error")
 
 // DefaultClient is a function rather than a var (as in the http pkg) because
 // it includes a circuit breaker so it should not be used as a client for
-// multiple services.
+// multiple services. Requests taking longer than 5 seconds count as failures
+// for the circuit breaker.
 func DefaultClient() *Client {
+	b := circuit.NewBreaker(100, time.Second)
+	b.SlowCallDuration = 5 * time.Second
+
 	return &Client{
 		HTTPClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 		RetryPolicy:    retry.Double(3),
-		CircuitBreaker: circuit.NewBreaker(100, time.Second),
+		CircuitBreaker: b,
 	}
 }
 
@@ -45,7 +52,8 @@ type Client struct {
 // Do acts the same as http.Client.Do except:
 //
 // - It retries for any errors or status codes 420, 429, and 5XX.
-// - Circuit breaker functionality can be configured.
+// - Circuit breaker functionality can be configured. Client errors (4XX) do
+// not trip the breaker and requests are not retried while it is open.
 // - 4XX or 5XX statuses will return an error with a nil response value.
 //
 func (c *Client) Do(r *http.Request) (*http.Response, error) {
@@ -64,10 +72,17 @@ func (c *Client) do(r *http.Request, p retry.Policy, b *circuit.Breaker) (*http.
 		var err error
 		resp, err = c.HTTPClient.Do(r)
 		if err != nil {
+			logErr := err
+			if ue, ok := err.(*url.Error); ok {
+				// The URL is logged without its query below
+				logErr = ue.Err
+			}
+			logger.Debug(logErr, requestFields(r))
 			return err
 		}
 
 		s := resp.StatusCode
+		logger.Debug("response received", requestFields(r), log.M{"status": s})
 		switch {
 		case s == 420 || s == 429:
 			alterPolicyFromRetryHeader(&p, resp.Header.Get("Retry-After"))
@@ -87,10 +102,17 @@ func (c *Client) do(r *http.Request, p retry.Policy, b *circuit.Breaker) (*http.
 	// Wrap the function in a circuit breaker if one is defined
 	if b != nil {
 		fn = func() error {
-			err := doHTTP()
-			// Don't trip on client errors
-			if errors.Cause(err) == Err4XX {
-				err = circuit.Ignore(err)
+			// Canceled requests are not counted as failures
+			err := b.RunContext(r.Context(), func(context.Context) error {
+				err := doHTTP()
+				// Don't trip on client errors
+				if err != nil && resp != nil && resp.StatusCode < 500 {
+					err = circuit.Ignore(err)
+				}
+				return err
+			})
+			if err == circuit.ErrBreakerOpen {
+				return retry.Stop(err)
 			}
 			return err
 		}
@@ -129,6 +151,12 @@ func (c *Client) DoUnmarshal(r *http.Request, x interface{}) (*http.Response, er
 	return resp, nil
 }
 
+// requestFields describes r for logging. The query is left out as it often
+// holds API keys or signed tokens.
+func requestFields(r *http.Request) log.KeyValues {
+	return log.KV("method", r.Method, "host", r.URL.Host, "path", r.URL.Path)
+}
+
 // alterPolicyFromRetryHeader adjusts a retry policy's sleep duration
 // based on headers sent back from a server.
 func alterPolicyFromRetryHeader(p *retry.Policy, h string) {
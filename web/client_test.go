@@ -4,10 +4,15 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/upgear/go-kit/circuit"
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logtest"
+	"github.com/upgear/go-kit/retry"
 	"github.com/upgear/go-kit/web"
 )
 
@@ -196,3 +201,135 @@ func TestDoCancelContext(t *testing.T) {
 		t.Fatal("expected nil response")
 	}
 }
+
+func TestDoCircuitBreaker(t *testing.T) {
+	var hits int
+	status := 404
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(status)
+	}))
+
+	c := web.Client{
+		HTTPClient:     &http.Client{},
+		CircuitBreaker: circuit.NewBreaker(2, time.Minute),
+	}
+	do := func() error {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Do(req)
+		return err
+	}
+
+	// Client errors do not trip the breaker
+	for i := 0; i < 3; i++ {
+		if err := do(); errors.Cause(err) != web.Err4XX {
+			t.Fatalf("expected Err4XX, got: %s", err)
+		}
+	}
+	if s := c.CircuitBreaker.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s after client errors, got: %s", circuit.StateClosed, s)
+	}
+
+	status = 503
+	do()
+	do()
+	if err := do(); err != circuit.ErrBreakerOpen {
+		t.Fatalf("expected %v, got: %v", circuit.ErrBreakerOpen, err)
+	}
+	if exp := 5; hits != exp {
+		t.Fatalf("expected %v requests to reach the server, got: %v", exp, hits)
+	}
+}
+
+func TestDoSlowCircuitBreaker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+
+	b := circuit.NewBreaker(1, time.Minute)
+	b.SlowCallDuration = 10 * time.Millisecond
+	c := web.Client{
+		HTTPClient:     &http.Client{},
+		CircuitBreaker: b,
+	}
+
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("expected nil error for a slow response, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := c.Do(req); err != circuit.ErrBreakerOpen {
+		t.Fatalf("expected %v, got: %v", circuit.ErrBreakerOpen, err)
+	}
+}
+
+func TestDoLogsWithoutQuery(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	c := web.Client{HTTPClient: &http.Client{}}
+
+	req, err := http.NewRequest("GET", ts.URL+"/a?api_key=s3cr3t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// A failed request logs its error without the URL
+	ts.Close()
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+
+	rec.AssertLogged(log.LevelDebug, "response received", log.M{"method": "GET", "path": "/a", "status": 200})
+	if n := len(rec.Lines()); n != 2 {
+		t.Fatalf("expected 2 lines, got:\n%s", rec)
+	}
+	if strings.Contains(rec.String(), "s3cr3t") {
+		t.Fatalf("expected the query to be left out, got:\n%s", rec)
+	}
+}
+
+func TestDoCircuitBreakerRetry(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(404)
+	}))
+
+	c := web.Client{
+		HTTPClient:     &http.Client{},
+		RetryPolicy:    &retry.Policy{Attempts: 3, Sleep: time.Millisecond, Factor: 2},
+		CircuitBreaker: circuit.NewBreaker(1, time.Minute),
+	}
+
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Do(req); errors.Cause(err) != web.Err4XX {
+			t.Fatalf("expected Err4XX, got: %v", err)
+		}
+	}
+
+	// A 404 is neither retried nor counted as a failure
+	if exp := 3; hits != exp {
+		t.Fatalf("expected %v requests to reach the server, got: %v", exp, hits)
+	}
+	if s := c.CircuitBreaker.State(); s != circuit.StateClosed {
+		t.Fatalf("expected %s after client errors, got: %s", circuit.StateClosed, s)
+	}
+}
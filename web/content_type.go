@@ -94,7 +94,7 @@ func Respond(w http.ResponseWriter, x interface{}, status int) {
 	w.WriteHeader(status)
 
 	if err := enc.Encode(x); err != nil {
-		log.Warn(errors.Wrap(err, "unable to marshal response to content type"))
+		logger.Warn(errors.Wrap(err, "unable to marshal response to content type"))
 	}
 }
 
@@ -111,13 +111,13 @@ func Error(w http.ResponseWriter, err error, status int) {
 
 	if status >= 500 {
 		// Override outgoing message as to not display internal errors externally
-		log.Error(err, log.M{"status": status})
+		logger.Error(err, log.M{"status": status})
 		err = errors.New(http.StatusText(status))
 		if err.Error() == "" {
 			err = errors.New(http.StatusText(http.StatusInternalServerError))
 		}
 	} else {
-		log.Warn(err, log.M{"status": status})
+		logger.Warn(err, log.M{"status": status})
 	}
 
 	Respond(
@@ -0,0 +1,29 @@
+package web_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logtest"
+	"github.com/upgear/go-kit/web"
+)
+
+func TestError(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	w := httptest.NewRecorder()
+	web.Error(w, errors.New("bad input"), http.StatusBadRequest)
+	rec.AssertLogged(log.LevelWarn, "bad input", log.M{"status": 400})
+	rec.NoErrors()
+
+	w = httptest.NewRecorder()
+	web.Error(w, errors.New("db down"), http.StatusInternalServerError)
+	rec.AssertLogged(log.LevelError, "db down", log.M{"status": 500})
+
+	if w.Code != 500 {
+		t.Fatalf("expected status 500, got: %v", w.Code)
+	}
+}
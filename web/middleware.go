@@ -8,11 +8,13 @@ import (
 	"github.com/upgear/go-kit/log"
 )
 
-// Logware logs requests.
+// Logware logs requests. A Logger which includes the request method and path
+// is added to the request context, see log.FromContext.
 func Logware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Info("new request", log.M{"method": r.Method, "path": r.URL.Path})
-		next.ServeHTTP(w, r)
+		l := log.FromContext(r.Context()).With(log.M{"method": r.Method, "path": r.URL.Path})
+		l.Info("new request")
+		next.ServeHTTP(w, r.WithContext(log.NewContext(r.Context(), l)))
 		return
 	})
 }
@@ -0,0 +1,26 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/upgear/go-kit/log"
+	"github.com/upgear/go-kit/log/logtest"
+	"github.com/upgear/go-kit/web"
+)
+
+func TestLogware(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	h := web.Logware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.InfoCtx(r.Context(), "handled")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abc", nil))
+
+	if n := len(rec.Lines()); n != 2 {
+		t.Fatalf("expected 2 lines, got:\n%s", rec)
+	}
+	// The handler's line includes the request context
+	rec.AssertLogged(log.LevelInfo, "handled", log.M{"method": "GET", "path": "/abc"})
+}
@@ -4,8 +4,14 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+
+	"github.com/upgear/go-kit/log"
 )
 
+// logger is used for all logging in this package. Its level can be set
+// separately with e.g. `LOG_LEVEL=info,web=debug`, see log.Named.
+var logger = log.Named("web")
+
 var ipRegex = regexp.MustCompile(`^([\.0-9]+):\d+$`)
 
 // ClientIP attempts to grab an IP address from the `X-Forwarded-For` header